@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/thecasualcoder/kube-template/pkg/logger"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultCommandTimeout = 30 * time.Second
+	defaultCommandRetries = 3
+	commandRetryInterval  = time.Second
+)
+
+// commandFailurePolicy decides what happens when a post-render command fails
+type commandFailurePolicy string
+
+const (
+	// ignoreFailure logs the failure and carries on
+	ignoreFailure commandFailurePolicy = "ignore"
+	// retryFailure runs the command again a few times before logging the failure
+	retryFailure commandFailurePolicy = "retry"
+	// stopOnFailure stops kube-template
+	stopOnFailure commandFailurePolicy = "stop"
+)
+
+func parseCommandFailurePolicy(value string) (commandFailurePolicy, error) {
+	switch policy := commandFailurePolicy(value); policy {
+	case "":
+		return ignoreFailure, nil
+	case ignoreFailure, retryFailure, stopOnFailure:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("\"%s\" is not one of ignore, retry or stop", value)
+	}
+}
+
+// postRenderCommand is run through the shell after a template is written
+type postRenderCommand struct {
+	command       string
+	timeout       time.Duration
+	onFailure     commandFailurePolicy
+	retries       int
+	retryInterval time.Duration
+}
+
+// run runs the command and logs its output.
+// An error is returned only if the command failed and the failure policy is stop.
+func (c *postRenderCommand) run() error {
+	attempts := 1
+	if c.onFailure == retryFailure {
+		attempts += c.retries
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = c.runOnce(); err == nil {
+			return nil
+		}
+
+		if attempt < attempts {
+			logger.Warnf("%v, retrying in %s (%d/%d)", err, c.retryInterval, attempt, c.retries)
+			time.Sleep(c.retryInterval)
+		}
+	}
+
+	if c.onFailure == stopOnFailure {
+		return err
+	}
+	logger.Errorf("%v", err)
+	return nil
+}
+
+// runOnce runs the command in its own process group
+// so that the whole group can be killed when it times out.
+func (c *postRenderCommand) runOnce() error {
+	output := &bytes.Buffer{}
+	command := exec.Command("sh", "-c", c.command)
+	command.Stdout = output
+	command.Stderr = output
+	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := command.Start(); err != nil {
+		return fmt.Errorf("error starting command \"%s\": %w", c.command, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- command.Wait()
+	}()
+
+	var timeout <-chan time.Time
+	if c.timeout > 0 {
+		timeout = time.After(c.timeout)
+	}
+
+	var err error
+	select {
+	case err = <-done:
+	case <-timeout:
+		_ = syscall.Kill(-command.Process.Pid, syscall.SIGKILL)
+		<-done
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		logger.Infof("[%s] %s", c.command, scanner.Text())
+	}
+
+	if err != nil {
+		return fmt.Errorf("error running command \"%s\": %w", c.command, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecasualcoder/kube-template/pkg/logger"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPostRenderCommand(t *testing.T) {
+	logs := &bytes.Buffer{}
+	logger.SetOutput(logs)
+	defer logger.SetOutput(os.Stderr)
+
+	t.Run("should log the output of the command", func(t *testing.T) {
+		logs.Reset()
+		command := &postRenderCommand{command: "echo reloaded", timeout: time.Second, onFailure: ignoreFailure}
+
+		err := command.run()
+
+		assert.NoError(t, err)
+		assert.Contains(t, logs.String(), "[echo reloaded] reloaded")
+	})
+
+	t.Run("should log failure if policy is ignore", func(t *testing.T) {
+		logs.Reset()
+		command := &postRenderCommand{command: "exit 1", timeout: time.Second, onFailure: ignoreFailure}
+
+		err := command.run()
+
+		assert.NoError(t, err)
+		assert.Contains(t, logs.String(), "error running command \"exit 1\"")
+	})
+
+	t.Run("should return error if policy is stop", func(t *testing.T) {
+		command := &postRenderCommand{command: "exit 1", timeout: time.Second, onFailure: stopOnFailure}
+
+		err := command.run()
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should retry the command if policy is retry", func(t *testing.T) {
+		logs.Reset()
+		command := &postRenderCommand{command: "echo attempt; exit 1", timeout: time.Second, onFailure: retryFailure, retries: 2}
+
+		err := command.run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, bytes.Count(logs.Bytes(), []byte("] attempt")))
+	})
+
+	t.Run("should kill the command once it times out", func(t *testing.T) {
+		command := &postRenderCommand{command: "sleep 5", timeout: 50 * time.Millisecond, onFailure: stopOnFailure}
+
+		start := time.Now()
+		err := command.run()
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "timed out after 50ms")
+		}
+		assert.True(t, time.Since(start) < time.Second)
+	})
+}
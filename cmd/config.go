@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/thecasualcoder/kube-template/pkg/config"
+)
+
+// loadConfig reads the config file if one is given and overrides its values with flags.
+// Templates given as flags, either files or inline, are rendered along with templates from the config file.
+// Flags which are not set by the user only fill in values missing from the file.
+func loadConfig(fs afero.Fs, cmd *cobra.Command) (*config.Config, error) {
+	flags := cmd.Flags()
+
+	cfg := &config.Config{}
+	if path, _ := flags.GetString(configFlag); path != "" {
+		var err error
+		if cfg, err = config.Parse(fs, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if flags.Changed(kubeConfigFlag) || cfg.Kubeconfig == "" {
+		cfg.Kubeconfig, _ = flags.GetString(kubeConfigFlag)
+	}
+
+	if flags.Changed(contextFlag) || cfg.Context == "" {
+		cfg.Context, _ = flags.GetString(contextFlag)
+	}
+
+	if flags.Changed(namespaceFlag) {
+		cfg.Namespace, _ = flags.GetString(namespaceFlag)
+	}
+
+	if flags.Changed(inClusterFlag) {
+		cfg.InCluster, _ = flags.GetBool(inClusterFlag)
+	}
+
+	if flags.Changed(asFlag) {
+		cfg.As, _ = flags.GetString(asFlag)
+	}
+
+	if flags.Changed(asGroupFlag) {
+		cfg.AsGroups, _ = flags.GetStringArray(asGroupFlag)
+	}
+
+	clusterFlags, _ := flags.GetStringArray(clusterFlag)
+	for _, clusterFlagValue := range clusterFlags {
+		name, context, err := parseClusterFlag(clusterFlagValue)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Clusters == nil {
+			cfg.Clusters = map[string]string{}
+		}
+		cfg.Clusters[name] = context
+	}
+
+	if flags.Changed(logLevelFlag) || cfg.Log == nil || cfg.Log.Level == "" {
+		level, _ := flags.GetString(logLevelFlag)
+		cfg.Log = &config.LogConfig{Level: level}
+	}
+
+	if flags.Changed(waitFlag) {
+		value, _ := flags.GetString(waitFlag)
+		wait, err := config.ParseWait(value)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Wait = wait
+	}
+
+	if flags.Changed(watchTemplatesFlag) {
+		cfg.WatchTemplates, _ = flags.GetBool(watchTemplatesFlag)
+	}
+
+	if flags.Changed(execFlag) {
+		if cfg.Exec == nil {
+			cfg.Exec = &config.ExecConfig{}
+		}
+		cfg.Exec.Command, _ = flags.GetString(execFlag)
+	}
+
+	if cfg.Exec != nil {
+		if flags.Changed(execReloadFlag) {
+			cfg.Exec.ReloadSignal, _ = flags.GetString(execReloadFlag)
+		}
+		if flags.Changed(execKillFlag) {
+			cfg.Exec.KillSignal, _ = flags.GetString(execKillFlag)
+		}
+		if flags.Changed(execTimeoutFlag) {
+			timeout, _ := flags.GetDuration(execTimeoutFlag)
+			cfg.Exec.KillTimeout = timeout.String()
+		}
+	}
+
+	templateFlags, _ := flags.GetStringArray(templateFlag)
+	for _, templateFlagValue := range templateFlags {
+		template, err := parseTemplateFlag(templateFlagValue)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Templates = append(cfg.Templates, template)
+	}
+
+	templateStringFlags, _ := flags.GetStringArray(templateStringFlag)
+	for _, templateStringFlagValue := range templateStringFlags {
+		template, err := parseTemplateStringFlag(templateStringFlagValue)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Templates = append(cfg.Templates, template)
+	}
+
+	// target flags apply to every template
+	for _, template := range cfg.Templates {
+		if flags.Changed(overwriteFlag) {
+			template.Overwrite, _ = flags.GetBool(overwriteFlag)
+		}
+		if flags.Changed(keepOnExitFlag) {
+			template.KeepOnExit, _ = flags.GetBool(keepOnExitFlag)
+		}
+		if flags.Changed(backupsFlag) {
+			template.Backups, _ = flags.GetInt(backupsFlag)
+		}
+	}
+
+	// the file is validated when it is parsed, flags only once they are merged into it
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("should validate flags merged into the config", func(t *testing.T) {
+		cmd := &cobra.Command{}
+		cmd.Flags().StringArrayP(templateFlag, "t", nil, "")
+		cmd.Flags().Int(backupsFlag, 0, "")
+		_ = cmd.Flags().Parse([]string{"--template", "a.tmpl:a.conf", "--backups", "-1"})
+
+		_, err := loadConfig(afero.NewMemMapFs(), cmd)
+
+		assert.EqualError(t, err, "templates[0]: backups cannot be negative")
+	})
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/thecasualcoder/kube-template/pkg/logger"
+	"github.com/thecasualcoder/kube-template/pkg/manager"
+	"io"
+	"time"
+)
+
+// dryRunDiffExitCode is the exit code of a dry run which found differences.
+// 1 is left for errors.
+const dryRunDiffExitCode = 2
+
+// runDryRun renders every template once all the data it needs is available
+// and prints a unified diff between the current contents of its target and the render to out.
+// Targets are never written and commands are never run.
+// An exitError with dryRunDiffExitCode is returned if any target would change.
+func runDryRun(ctx context.Context, m manager.Manager, templateArgs []templateArg, timeout time.Duration, out io.Writer) error {
+	changed := 0
+	err := renderEach(ctx, m, templateArgs, timeout, func(templateArg templateArg, buf *bytes.Buffer) error {
+		diff, err := templateArg.target.diff(buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		if diff == "" {
+			logger.Infof("%s would not change", templateArg.target.path)
+			return nil
+		}
+
+		changed++
+		_, err = io.WriteString(out, diff)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if changed != 0 {
+		return &exitError{
+			code:    dryRunDiffExitCode,
+			message: fmt.Sprintf("%d of %d targets would change", changed, len(templateArgs)),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecasualcoder/kube-template/mock"
+	"github.com/thecasualcoder/kube-template/pkg/manager"
+	v1 "k8s.io/api/core/v1"
+	"testing"
+	"time"
+)
+
+func TestRunDryRun(t *testing.T) {
+	source := `{{ with endpoints "default" "nginx" }}{{ range .Subsets }}{{ range .Addresses }}server {{ .IP }}
+{{ end }}{{ end }}{{ end }}`
+	endpoints := &v1.Endpoints{
+		Subsets: []v1.EndpointSubset{
+			{Addresses: []v1.EndpointAddress{{IP: "10.0.0.100"}, {IP: "10.0.0.101"}}},
+		},
+	}
+
+	t.Run("should print a diff without writing the target or running the command", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "nginx.conf", []byte("server 10.0.0.100\n"), 0644)
+		templateArgs := []templateArg{{
+			source:  source,
+			target:  target{fs: fs, path: "nginx.conf", uid: -1, gid: -1},
+			command: &postRenderCommand{command: "touch reloaded"},
+		}}
+		eventChan := make(chan struct{}, 1)
+		eventChan <- struct{}{}
+		out := &bytes.Buffer{}
+
+		m := mock.NewMockManager(ctrl)
+		gomock.InOrder(
+			m.EXPECT().Endpoints("default", "nginx").Return(nil, manager.ErrDataNotReady),
+			m.EXPECT().Endpoints("default", "nginx").Return(endpoints, nil),
+		)
+		m.EXPECT().EventChan().Return(eventChan).AnyTimes()
+		m.EXPECT().ErrorChan().Return(make(chan error)).AnyTimes()
+
+		err := runDryRun(context.Background(), m, templateArgs, time.Second, out)
+
+		var exitErr *exitError
+		if assert.True(t, errors.As(err, &exitErr)) {
+			assert.Equal(t, dryRunDiffExitCode, exitErr.code)
+		}
+		assert.Equal(t, `--- nginx.conf
++++ nginx.conf (rendered)
+@@ -1 +1,2 @@
+ server 10.0.0.100
++server 10.0.0.101
+`, out.String())
+		contents, _ := afero.ReadFile(fs, "nginx.conf")
+		assert.Equal(t, "server 10.0.0.100\n", string(contents))
+		exists, _ := afero.Exists(afero.NewOsFs(), "reloaded")
+		assert.False(t, exists)
+	})
+
+	t.Run("should return no error if no target would change", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "nginx.conf", []byte("server 10.0.0.100\nserver 10.0.0.101\n"), 0644)
+		out := &bytes.Buffer{}
+
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Endpoints("default", "nginx").Return(endpoints, nil)
+
+		templateArgs := []templateArg{{source: source, target: target{fs: fs, path: "nginx.conf", uid: -1, gid: -1}}}
+		err := runDryRun(context.Background(), m, templateArgs, time.Second, out)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "", out.String())
+	})
+
+	t.Run("should diff a target which does not exist as empty", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		fs := afero.NewMemMapFs()
+		out := &bytes.Buffer{}
+
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Endpoints("default", "nginx").Return(endpoints, nil)
+
+		templateArgs := []templateArg{{source: source, target: target{fs: fs, path: "nginx.conf", uid: -1, gid: -1}}}
+		err := runDryRun(context.Background(), m, templateArgs, time.Second, out)
+
+		assert.Error(t, err)
+		assert.Contains(t, out.String(), "+server 10.0.0.100\n+server 10.0.0.101\n")
+		exists, _ := afero.Exists(fs, "nginx.conf")
+		assert.False(t, exists)
+	})
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/thecasualcoder/kube-template/pkg/logger"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// exitError is returned when kube-template has to exit with a specific exit code,
+// like the exit code of the child process
+type exitError struct {
+	code int
+	// message describes why. Defaults to the exit code of the child process
+	message string
+}
+
+func (e *exitError) Error() string {
+	if e.message != "" {
+		return e.message
+	}
+	return fmt.Sprintf("child process exited with code %d", e.code)
+}
+
+// childProcess supervises the process started through exec.
+// It is not thread safe and is meant to be used from the run loop only.
+type childProcess struct {
+	execCommand execCommand
+	command     *exec.Cmd
+	exited      chan struct{}
+}
+
+func newChildProcess(execCommand execCommand) *childProcess {
+	return &childProcess{execCommand: execCommand}
+}
+
+// start runs the command in the background.
+// The exited channel is closed once the command exits.
+func (c *childProcess) start() error {
+	command := exec.Command(c.execCommand.command, c.execCommand.args...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	if err := command.Start(); err != nil {
+		return fmt.Errorf("error starting process %s: %v", command.Path, err)
+	}
+	logger.Infof("started process %s with pid %d", command.Path, command.Process.Pid)
+
+	exited := make(chan struct{})
+	go func() {
+		if err := command.Wait(); err != nil {
+			logger.Infof("process %s exited: %v", command.Path, err)
+		}
+		close(exited)
+	}()
+
+	c.command = command
+	c.exited = exited
+	return nil
+}
+
+// reload signals the process with the reload signal if one is configured.
+// Otherwise the process is restarted.
+func (c *childProcess) reload() error {
+	if c.execCommand.reloadSignal != nil {
+		logger.Infof("sending %s to process %s", c.execCommand.reloadSignal, c.command.Path)
+		return c.command.Process.Signal(c.execCommand.reloadSignal)
+	}
+
+	logger.Infof("restarting process %s", c.command.Path)
+	if err := c.stop(c.execCommand.killSignal); err != nil {
+		return err
+	}
+	return c.start()
+}
+
+// stop sends the signal to the process and waits for it to exit.
+// The process is killed if it is still running after the kill timeout.
+func (c *childProcess) stop(signal os.Signal) error {
+	select {
+	case <-c.exited:
+		return nil
+	default:
+	}
+
+	if err := c.command.Process.Signal(signal); err != nil {
+		return err
+	}
+
+	select {
+	case <-c.exited:
+		return nil
+	case <-time.After(c.execCommand.killTimeout):
+		logger.Warnf("process %s did not exit within %s, killing it", c.command.Path, c.execCommand.killTimeout)
+		if err := c.command.Process.Kill(); err != nil {
+			return err
+		}
+		<-c.exited
+		return nil
+	}
+}
+
+// exitCode of the process once it has exited.
+// A process terminated by a signal has the exit code 128 + signal like a shell reports it.
+func (c *childProcess) exitCode() int {
+	state := c.command.ProcessState
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
+
+// exitError returns an error carrying the exit code of an exited process.
+// It returns nil if the process exited successfully.
+func (c *childProcess) exitError() error {
+	if code := c.exitCode(); code != 0 {
+		return &exitError{code: code}
+	}
+	return nil
+}
+
+var signals = map[string]os.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+// parseSignal converts a signal name like "SIGHUP" or "hup" into a signal.
+// Empty name means defaultSignal.
+func parseSignal(name string, defaultSignal os.Signal) (os.Signal, error) {
+	if name == "" {
+		return defaultSignal, nil
+	}
+
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	signal, ok := signals[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown signal \"%s\"", name)
+	}
+	return signal, nil
+}
+
+// splitCommand splits a command line into arguments the way a POSIX shell does.
+// Single quotes preserve everything literally, double quotes allow escaping
+// \", \\, \$ and \` and a backslash outside quotes escapes the next character.
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("command ends with an escape character")
+			}
+			i++
+			current.WriteRune(runes[i])
+			inArg = true
+		case r == '\'':
+			i++
+			for ; i < len(runes) && runes[i] != '\''; i++ {
+				current.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("command has an unterminated single quote")
+			}
+			inArg = true
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]) {
+					i++
+				}
+				current.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("command has an unterminated double quote")
+			}
+			inArg = true
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSplitCommand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		command  string
+		expected []string
+	}{
+		{"plain arguments", "nginx -g daemon", []string{"nginx", "-g", "daemon"}},
+		{"repeated whitespace", "  nginx \t -g  ", []string{"nginx", "-g"}},
+		{"single quotes", `nginx -g 'daemon off;'`, []string{"nginx", "-g", "daemon off;"}},
+		{"double quotes with escapes", `echo "say \"hi\" \n"`, []string{"echo", `say "hi" \n`}},
+		{"escaped space", `cat my\ file`, []string{"cat", "my file"}},
+		{"quotes joined to a word", `--name='a b'"c"`, []string{"--name=a bc"}},
+		{"empty quotes", `echo ''`, []string{"echo", ""}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run("should split "+testCase.name, func(t *testing.T) {
+			args, err := splitCommand(testCase.command)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, args)
+		})
+	}
+
+	t.Run("should return error for unterminated quotes", func(t *testing.T) {
+		_, err := splitCommand(`nginx -g 'daemon off;`)
+
+		assert.Error(t, err)
+
+		_, err = splitCommand(`nginx -g "daemon off;`)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestParseSignal(t *testing.T) {
+	t.Run("should parse signal names with or without prefix", func(t *testing.T) {
+		signal, err := parseSignal("SIGHUP", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, syscall.SIGHUP, signal)
+
+		signal, err = parseSignal("usr1", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, syscall.SIGUSR1, signal)
+	})
+
+	t.Run("should return default signal for empty name", func(t *testing.T) {
+		signal, err := parseSignal("", os.Interrupt)
+
+		assert.NoError(t, err)
+		assert.Equal(t, os.Interrupt, signal)
+	})
+
+	t.Run("should return error for unknown signal", func(t *testing.T) {
+		_, err := parseSignal("SIGFOO", nil)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestChildProcess(t *testing.T) {
+	t.Run("should report the exit code of the process", func(t *testing.T) {
+		child := newChildProcess(execCommand{command: "sh", args: []string{"-c", "exit 3"}})
+
+		if assert.NoError(t, child.start()) {
+			<-child.exited
+			assert.Equal(t, &exitError{code: 3}, child.exitError())
+		}
+	})
+
+	t.Run("should kill the process if it does not stop within the kill timeout", func(t *testing.T) {
+		child := newChildProcess(execCommand{
+			command:     "sh",
+			args:        []string{"-c", "trap '' INT; exec sleep 5"},
+			killTimeout: 100 * time.Millisecond,
+		})
+
+		if assert.NoError(t, child.start()) {
+			time.Sleep(50 * time.Millisecond)
+			assert.NoError(t, child.stop(os.Interrupt))
+			assert.Equal(t, 128+int(syscall.SIGKILL), child.exitCode())
+		}
+	})
+
+	t.Run("should restart the process on reload without a reload signal", func(t *testing.T) {
+		child := newChildProcess(execCommand{
+			command:     "sleep",
+			args:        []string{"5"},
+			killSignal:  syscall.SIGTERM,
+			killTimeout: time.Second,
+		})
+
+		if assert.NoError(t, child.start()) {
+			pid := child.command.Process.Pid
+
+			assert.NoError(t, child.reload())
+			assert.NotEqual(t, pid, child.command.Process.Pid)
+			assert.NoError(t, child.stop(syscall.SIGTERM))
+		}
+	})
+}
@@ -0,0 +1,645 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"github.com/thecasualcoder/kube-template/pkg/kubernetes"
+	"github.com/thecasualcoder/kube-template/pkg/manager"
+	appsV1 "k8s.io/api/apps/v1"
+	coordinationV1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions available to templates.
+// Functions reading from the cluster take an optional last argument selecting another cluster, like "@eu-west".
+// Values of secrets read through them are added to secrets.
+func templateFuncs(m manager.Manager, secrets *secretValues) template.FuncMap {
+	f := &funcs{manager: m, secrets: secrets, objectClusters: map[types.UID]string{}}
+
+	return template.FuncMap{
+		"clusters":          f.clusters,
+		"clusterOf":         f.clusterOf,
+		"endpoints":         f.endpoints,
+		"pods":              f.pods,
+		"service":           f.service,
+		"services":          f.services,
+		"configMap":         f.configMap,
+		"configMapKey":      f.configMapKey,
+		"secret":            f.secret,
+		"secretKey":         f.secretKey,
+		"endpointSlices":    f.endpointSlices,
+		"nodes":             f.nodes,
+		"nodeAddress":       nodeAddress,
+		"nodeReady":         nodeReady,
+		"readyAddresses":    f.readyAddresses,
+		"notReadyAddresses": f.notReadyAddresses,
+		"portByName":        portByName,
+		"readyPods":         readyPods,
+		"terminatingPods":   terminatingPods,
+		"deployment":        f.deployment,
+		"statefulSet":       f.statefulSet,
+		"statefulSetDNS":    statefulSetDNS,
+		"daemonSet":         f.daemonSet,
+		"podsOf":            f.podsOf,
+		"ownerOf":           f.ownerOf,
+		"lease":             f.lease,
+		"leaseHolderIP":     f.leaseHolderIP,
+		"resource":          f.resource,
+		"resources":         f.resources,
+	}
+}
+
+// funcs are template functions which read from the manager of a cluster
+type funcs struct {
+	manager manager.Manager
+	secrets *secretValues
+	// objectClusters are the names of the clusters objects were read from, by UID
+	objectClusters map[types.UID]string
+}
+
+// cluster returns the manager and the name of the cluster given as the last argument of a function, like "@eu-west".
+// It is the default cluster if no cluster is given. The name is empty if the manager does not name its clusters.
+func (f *funcs) cluster(cluster []string) (manager.Manager, string, error) {
+	multiCluster, ok := f.manager.(manager.MultiCluster)
+	if len(cluster) == 0 {
+		if !ok {
+			return f.manager, "", nil
+		}
+		return f.manager, multiCluster.DefaultCluster(), nil
+	}
+	if len(cluster) > 1 {
+		return nil, "", fmt.Errorf("expected a single cluster, got %s", strings.Join(cluster, " "))
+	}
+
+	name := strings.TrimPrefix(cluster[0], "@")
+	if !ok {
+		return nil, "", fmt.Errorf("unknown cluster %q", name)
+	}
+	m, err := multiCluster.Cluster(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return m, name, nil
+}
+
+// addCluster records the name of the cluster the object, or every item of a list, was read from.
+// Objects are looked up by their UID, as objects read through templates are never modified.
+func (f *funcs) addCluster(object runtime.Object, cluster string) {
+	if cluster == "" {
+		return
+	}
+	if meta.IsListType(object) {
+		_ = meta.EachListItem(object, func(item runtime.Object) error {
+			f.addCluster(item, cluster)
+			return nil
+		})
+		return
+	}
+
+	if accessor, err := meta.Accessor(object); err == nil && accessor.GetUID() != "" {
+		f.objectClusters[accessor.GetUID()] = cluster
+	}
+}
+
+// clusterOf returns the name of the cluster the object was read from, like a pod of pods "default" "app=api" "@eu-west".
+// It is empty for objects not read through templates or when clusters are not named.
+func (f *funcs) clusterOf(object interface{}) string {
+	if object == nil {
+		return ""
+	}
+
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		// templates pass objects by value, like the items of a list, while their metadata has pointer receivers
+		pointer := reflect.New(reflect.TypeOf(object))
+		pointer.Elem().Set(reflect.ValueOf(object))
+		if accessor, err = meta.Accessor(pointer.Interface()); err != nil {
+			return ""
+		}
+	}
+	return f.objectClusters[accessor.GetUID()]
+}
+
+// clusters returns the names of every cluster, including the default one
+func (f *funcs) clusters() []string {
+	multiCluster, ok := f.manager.(manager.MultiCluster)
+	if !ok {
+		return []string{}
+	}
+	return multiCluster.Clusters()
+}
+
+func (f *funcs) endpoints(namespace, name string, cluster ...string) (*v1.Endpoints, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := m.Endpoints(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(endpoints, clusterName)
+	return endpoints, nil
+}
+
+func (f *funcs) pods(namespace, labels string, cluster ...string) (*v1.PodList, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := m.PodsWithLabels(namespace, labels)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(pods, clusterName)
+	return pods, nil
+}
+
+func (f *funcs) service(namespace, name string, cluster ...string) (*v1.Service, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := m.Service(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(service, clusterName)
+	return service, nil
+}
+
+func (f *funcs) services(namespace, labels string, cluster ...string) (*v1.ServiceList, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := m.ServicesWithLabels(namespace, labels)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(services, clusterName)
+	return services, nil
+}
+
+func (f *funcs) configMap(namespace, name string, cluster ...string) (*v1.ConfigMap, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	configMap, err := m.ConfigMap(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(configMap, clusterName)
+	return configMap, nil
+}
+
+func (f *funcs) configMapKey(namespace, name, key string, cluster ...string) (string, error) {
+	configMap, err := f.configMap(namespace, name, cluster...)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := configMap.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key \"%s\" not found in config map %s/%s", key, configMap.Namespace, configMap.Name)
+	}
+	return value, nil
+}
+
+func (f *funcs) secret(namespace, name string, cluster ...string) (*v1.Secret, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := m.Secret(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(secret, clusterName)
+
+	for _, value := range secret.Data {
+		f.secrets.add(string(value))
+	}
+	return secret, nil
+}
+
+// secretKey returns the value of the key decoded as a string
+func (f *funcs) secretKey(namespace, name, key string, cluster ...string) (string, error) {
+	m, _, err := f.cluster(cluster)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := m.Secret(namespace, name)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key \"%s\" not found in secret %s/%s", key, secret.Namespace, secret.Name)
+	}
+	f.secrets.add(string(value))
+	return string(value), nil
+}
+
+func (f *funcs) endpointSlices(namespace, serviceName string, cluster ...string) ([]kubernetes.SliceEndpoint, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := m.EndpointSlices(namespace, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	// endpoints are copied as the manager serves the same ones to every template
+	clusterEndpoints := make([]kubernetes.SliceEndpoint, len(endpoints))
+	for i, endpoint := range endpoints {
+		endpoint.Cluster = clusterName
+		clusterEndpoints[i] = endpoint
+	}
+	return clusterEndpoints, nil
+}
+
+func (f *funcs) nodes(labels string, cluster ...string) (*v1.NodeList, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := m.Nodes(labels)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(nodes, clusterName)
+	return nodes, nil
+}
+
+func (f *funcs) deployment(namespace, name string, cluster ...string) (*appsV1.Deployment, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := m.Deployment(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(deployment, clusterName)
+	return deployment, nil
+}
+
+func (f *funcs) statefulSet(namespace, name string, cluster ...string) (*appsV1.StatefulSet, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	statefulSet, err := m.StatefulSet(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(statefulSet, clusterName)
+	return statefulSet, nil
+}
+
+func (f *funcs) daemonSet(namespace, name string, cluster ...string) (*appsV1.DaemonSet, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	daemonSet, err := m.DaemonSet(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(daemonSet, clusterName)
+	return daemonSet, nil
+}
+
+func (f *funcs) podsOf(kind, namespace, name string, cluster ...string) (*v1.PodList, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := m.PodsOf(kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(pods, clusterName)
+	return pods, nil
+}
+
+// ownerOf returns the top-level controller of the pod, like its deployment.
+// It is nil if the pod has no controller.
+// The owner is looked up in the cluster the pod was read from unless another one is given.
+func (f *funcs) ownerOf(pod v1.Pod, cluster ...string) (*unstructured.Unstructured, error) {
+	if podCluster := f.clusterOf(pod); len(cluster) == 0 && podCluster != "" {
+		cluster = []string{podCluster}
+	}
+
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := m.Owner(&pod)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(owner, clusterName)
+	return owner, nil
+}
+
+func (f *funcs) lease(namespace, name string, cluster ...string) (*coordinationV1.Lease, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	lease, err := m.Lease(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(lease, clusterName)
+	return lease, nil
+}
+
+// leaseHolderIP returns the IP of the pod holding the lease, looked up among the pods matching labels.
+// Leader election usually identifies the holder by the name of its pod, optionally followed by _ and a unique id.
+// It is empty if the lease has no holder or the holder is not one of the pods.
+func (f *funcs) leaseHolderIP(namespace, name, labels string, cluster ...string) (string, error) {
+	m, _, err := f.cluster(cluster)
+	if err != nil {
+		return "", err
+	}
+
+	lease, err := m.Lease(namespace, name)
+	if err != nil {
+		return "", err
+	}
+
+	// pods are watched even while the lease has no holder, so that the template renders once one is elected
+	pods, err := m.PodsWithLabels(namespace, labels)
+	if err != nil {
+		return "", err
+	}
+
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return "", nil
+	}
+	holder := *lease.Spec.HolderIdentity
+	for _, pod := range pods.Items {
+		if holder == pod.Name || strings.HasPrefix(holder, pod.Name+"_") {
+			return pod.Status.PodIP, nil
+		}
+	}
+	return "", nil
+}
+
+func (f *funcs) resource(resource, namespace, name string, cluster ...string) (*unstructured.Unstructured, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+	object, err := m.Resource(resource, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(object, clusterName)
+
+	if isSecrets(resource) {
+		f.addUnstructuredSecret(object)
+	}
+	return object, nil
+}
+
+func (f *funcs) resources(resource, namespace, labels string, cluster ...string) (*unstructured.UnstructuredList, error) {
+	m, clusterName, err := f.cluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+	list, err := m.Resources(resource, namespace, labels)
+	if err != nil {
+		return nil, err
+	}
+	f.addCluster(list, clusterName)
+
+	if isSecrets(resource) {
+		for i := range list.Items {
+			f.addUnstructuredSecret(&list.Items[i])
+		}
+	}
+	return list, nil
+}
+
+var secretsResource = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+// isSecrets is true if resource is the core v1 secrets resource
+func isSecrets(resource string) bool {
+	gvr, err := kubernetes.ParseGroupVersionResource(resource)
+	return err == nil && gvr == secretsResource
+}
+
+// addUnstructuredSecret adds the values of a secret fetched as an unstructured object.
+// Data is base64 encoded in it, so both the encoded and the decoded values are added.
+func (f *funcs) addUnstructuredSecret(secret *unstructured.Unstructured) {
+	data, _, _ := unstructured.NestedStringMap(secret.Object, "data")
+	for _, value := range data {
+		f.secrets.add(value)
+		if decoded, err := base64.StdEncoding.DecodeString(value); err == nil {
+			f.secrets.add(string(decoded))
+		}
+	}
+
+	stringData, _, _ := unstructured.NestedStringMap(secret.Object, "stringData")
+	for _, value := range stringData {
+		f.secrets.add(value)
+	}
+}
+
+// nodeAddress returns the first address of the node with the type,
+// like InternalIP, ExternalIP or Hostname. It is empty if the node has none.
+func nodeAddress(addressType string, node v1.Node) string {
+	for _, address := range node.Status.Addresses {
+		if string(address.Type) == addressType {
+			return address.Address
+		}
+	}
+	return ""
+}
+
+// nodeReady is true if the Ready condition of the node is True
+func nodeReady(node v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// statefulSetDNS returns the stable DNS names of the pods of the stateful set,
+// like redis-0.redis and redis-1.redis, in the order of their ordinals.
+// Replicas default to 1 like they do in Kubernetes.
+func statefulSetDNS(statefulSet appsV1.StatefulSet) []string {
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+
+	names := make([]string, 0, replicas)
+	for ordinal := int32(0); ordinal < replicas; ordinal++ {
+		names = append(names, fmt.Sprintf("%s-%d.%s", statefulSet.Name, ordinal, statefulSet.Spec.ServiceName))
+	}
+	return names
+}
+
+// endpointAddress is an address of endpoints along with the cluster the endpoints were read from
+type endpointAddress struct {
+	v1.EndpointAddress
+	Cluster string
+}
+
+// readyAddresses returns the ready addresses of all subsets of the endpoints, sorted by IP
+func (f *funcs) readyAddresses(endpoints v1.Endpoints) []endpointAddress {
+	var addresses []v1.EndpointAddress
+	for _, subset := range endpoints.Subsets {
+		addresses = append(addresses, subset.Addresses...)
+	}
+	return sortAddresses(addresses, f.clusterOf(endpoints))
+}
+
+// notReadyAddresses returns the addresses of all subsets of the endpoints which are not ready, sorted by IP
+func (f *funcs) notReadyAddresses(endpoints v1.Endpoints) []endpointAddress {
+	var addresses []v1.EndpointAddress
+	for _, subset := range endpoints.Subsets {
+		addresses = append(addresses, subset.NotReadyAddresses...)
+	}
+	return sortAddresses(addresses, f.clusterOf(endpoints))
+}
+
+// sortAddresses sorts addresses by IP and removes addresses listed in more than one subset
+func sortAddresses(addresses []v1.EndpointAddress, cluster string) []endpointAddress {
+	sort.SliceStable(addresses, func(i, j int) bool {
+		return lessIP(addresses[i].IP, addresses[j].IP)
+	})
+
+	sorted := []endpointAddress{}
+	for _, address := range addresses {
+		if len(sorted) == 0 || sorted[len(sorted)-1].IP != address.IP {
+			sorted = append(sorted, endpointAddress{EndpointAddress: address, Cluster: cluster})
+		}
+	}
+	return sorted
+}
+
+// lessIP compares IPs numerically, so that 10.0.0.2 comes before 10.0.0.10
+func lessIP(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return a < b
+	}
+	return bytes.Compare(ipA.To16(), ipB.To16()) < 0
+}
+
+// portByName returns the port with the name from any subset of the endpoints.
+// It is 0 if no subset has it, like while the endpoints have no addresses.
+func portByName(name string, endpoints v1.Endpoints) int32 {
+	for _, subset := range endpoints.Subsets {
+		for _, port := range subset.Ports {
+			if port.Name == name {
+				return port.Port
+			}
+		}
+	}
+	return 0
+}
+
+// podReady is true if the Ready condition of the pod is True
+func podReady(pod v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// readyPods returns the pods which are ready and not terminating, sorted by name
+func readyPods(pods v1.PodList) []v1.Pod {
+	ready := []v1.Pod{}
+	for _, pod := range pods.Items {
+		if podReady(pod) && pod.DeletionTimestamp == nil {
+			ready = append(ready, pod)
+		}
+	}
+	return sortPods(ready)
+}
+
+// terminatingPods returns the pods which are being deleted, sorted by name
+func terminatingPods(pods v1.PodList) []v1.Pod {
+	terminating := []v1.Pod{}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			terminating = append(terminating, pod)
+		}
+	}
+	return sortPods(terminating)
+}
+
+func sortPods(pods []v1.Pod) []v1.Pod {
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods
+}
+
+// secretValues collects the values of secrets read during a single render
+// so that they can be removed from errors before they are logged
+type secretValues struct {
+	values []string
+}
+
+func (s *secretValues) add(value string) {
+	if value != "" {
+		s.values = append(s.values, value)
+	}
+}
+
+// redact replaces every secret value in message
+func (s *secretValues) redact(message string) string {
+	// longer values first, so that a value containing another one is redacted entirely
+	sort.Slice(s.values, func(i, j int) bool {
+		return len(s.values[i]) > len(s.values[j])
+	})
+
+	for _, value := range s.values {
+		message = strings.ReplaceAll(message, value, "[redacted]")
+	}
+	return message
+}
@@ -0,0 +1,513 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecasualcoder/kube-template/mock"
+	"github.com/thecasualcoder/kube-template/pkg/kubernetes"
+	"github.com/thecasualcoder/kube-template/pkg/manager"
+	appsV1 "k8s.io/api/apps/v1"
+	coordinationV1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/api/core/v1"
+	apiV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+	"testing"
+)
+
+func TestTemplateFuncs_Services(t *testing.T) {
+	t.Run("should render template with service ports", func(t *testing.T) {
+		source := `{{- with service "default" "nginx" -}}
+{{ .Spec.ClusterIP }}{{ range .Spec.Ports }}{{ if eq .Name "metrics" }}:{{ .Port }}{{ end }}{{ end }}
+{{- end }}
+{{- range (services "default" "app=nginx").Items }} {{ .Name }}{{ end }}`
+		target := &bytes.Buffer{}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := mock.NewMockManager(ctrl)
+		service := v1.Service{
+			ObjectMeta: apiV1.ObjectMeta{Name: "nginx"},
+			Spec: v1.ServiceSpec{
+				ClusterIP: "10.96.0.10",
+				Ports: []v1.ServicePort{
+					{Name: "http", Port: 80},
+					{Name: "metrics", Port: 9100},
+				},
+			},
+		}
+		m.EXPECT().Service("default", "nginx").Return(&service, nil)
+		m.EXPECT().ServicesWithLabels("default", "app=nginx").Return(&v1.ServiceList{Items: []v1.Service{service}}, nil)
+
+		err := renderTemplate(m, source, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "10.96.0.10:9100 nginx", target.String())
+	})
+}
+
+func TestTemplateFuncs_ConfigMapAndSecret(t *testing.T) {
+	configMap := &v1.ConfigMap{
+		ObjectMeta: apiV1.ObjectMeta{Namespace: "default", Name: "flags"},
+		Data:       map[string]string{"feature": "enabled"},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: apiV1.ObjectMeta{Namespace: "default", Name: "basic-auth"},
+		Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("hunter2")},
+	}
+
+	t.Run("should render keys of config maps and decoded keys of secrets", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().ConfigMap("default", "flags").Return(configMap, nil).Times(2)
+		m.EXPECT().Secret("default", "basic-auth").Return(secret, nil).Times(2)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ configMapKey "default" "flags" "feature" }} {{ (configMap "default" "flags").Name }} `+
+			`{{ secretKey "default" "basic-auth" "password" }} {{ printf "%s" (secret "default" "basic-auth").Data.username }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "enabled flags hunter2 admin", target.String())
+	})
+
+	t.Run("should return error naming the missing key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Secret("default", "basic-auth").Return(secret, nil)
+
+		err := renderTemplate(m, `{{ secretKey "default" "basic-auth" "token" }}`, &bytes.Buffer{})
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), `key "token" not found in secret default/basic-auth`)
+		}
+	})
+
+	t.Run("should not leak secret values in errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Secret("default", "basic-auth").Return(secret, nil).Times(2)
+		m.EXPECT().PodsWithLabels("default", "hunter2").Return(nil, fmt.Errorf("invalid label selector hunter2"))
+
+		err := renderTemplate(m, `{{ $s := secret "default" "basic-auth" }}{{ secretKey "default" "basic-auth" "password" | pods "default" }}`, &bytes.Buffer{})
+
+		if assert.Error(t, err) {
+			assert.NotContains(t, err.Error(), "hunter2")
+			assert.Contains(t, err.Error(), "invalid label selector [redacted]")
+		}
+	})
+}
+
+func TestTemplateFuncs_Nodes(t *testing.T) {
+	t.Run("should render addresses, labels and readiness of nodes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Nodes("role=proxy").Return(&v1.NodeList{
+			Items: []v1.Node{
+				{
+					ObjectMeta: apiV1.ObjectMeta{Labels: map[string]string{"topology.kubernetes.io/zone": "a"}},
+					Status: v1.NodeStatus{
+						Addresses: []v1.NodeAddress{
+							{Type: v1.NodeHostName, Address: "node-1"},
+							{Type: v1.NodeInternalIP, Address: "192.168.0.10"},
+						},
+						Conditions: []v1.NodeCondition{
+							{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse},
+							{Type: v1.NodeReady, Status: v1.ConditionTrue},
+						},
+					},
+				},
+				{
+					ObjectMeta: apiV1.ObjectMeta{Labels: map[string]string{"topology.kubernetes.io/zone": "b"}},
+					Status: v1.NodeStatus{
+						Addresses:  []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "192.168.0.11"}},
+						Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionUnknown}},
+					},
+				},
+			},
+		}, nil)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ range (nodes "role=proxy").Items -}}
+{{ nodeAddress "InternalIP" . }} {{ . | nodeAddress "Hostname" }} {{ index .Labels "topology.kubernetes.io/zone" }} {{ nodeReady . }}
+{{ end }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "192.168.0.10 node-1 a true\n192.168.0.11  b false\n", target.String())
+	})
+}
+
+func TestTemplateFuncs_EndpointSlices(t *testing.T) {
+	t.Run("should render ready endpoints of endpoint slices", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().EndpointSlices("default", "nginx").Return([]kubernetes.SliceEndpoint{
+			{Addresses: []string{"10.0.0.1"}, Ports: []kubernetes.SlicePort{{Name: "http", Port: 8080}}, Ready: true},
+			{Addresses: []string{"10.0.0.2"}, Ports: []kubernetes.SlicePort{{Name: "http", Port: 8080}}},
+		}, nil)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ range endpointSlices "default" "nginx" }}{{ if .Ready }}{{ $ip := index .Addresses 0 }}{{ range .Ports }}{{ $ip }}:{{ .Port }} {{ end }}{{ end }}{{ end }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "10.0.0.1:8080 ", target.String())
+	})
+}
+
+func TestTemplateFuncs_Workloads(t *testing.T) {
+	replicas := int32(3)
+
+	t.Run("should render stateful set DNS names", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().StatefulSet("default", "redis").Return(&appsV1.StatefulSet{
+			ObjectMeta: apiV1.ObjectMeta{Name: "redis"},
+			Spec:       appsV1.StatefulSetSpec{ServiceName: "redis-headless", Replicas: &replicas},
+		}, nil)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ range statefulSet "default" "redis" | statefulSetDNS }}{{ . }}:6379 {{ end }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "redis-0.redis-headless:6379 redis-1.redis-headless:6379 redis-2.redis-headless:6379 ", target.String())
+	})
+
+	t.Run("should render status of deployments and daemon sets", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Deployment("default", "web").Return(&appsV1.Deployment{
+			Spec:   appsV1.DeploymentSpec{Replicas: &replicas},
+			Status: appsV1.DeploymentStatus{ReadyReplicas: 2},
+		}, nil)
+		m.EXPECT().DaemonSet("kube-system", "proxy").Return(&appsV1.DaemonSet{
+			Status: appsV1.DaemonSetStatus{NumberReady: 4},
+		}, nil)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ with deployment "default" "web" }}{{ .Status.ReadyReplicas }}/{{ .Spec.Replicas }}{{ end }} {{ (daemonSet "kube-system" "proxy").Status.NumberReady }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2/3 4", target.String())
+	})
+}
+
+func TestStatefulSetDNS(t *testing.T) {
+	t.Run("should default to a single replica", func(t *testing.T) {
+		statefulSet := appsV1.StatefulSet{
+			ObjectMeta: apiV1.ObjectMeta{Name: "redis"},
+			Spec:       appsV1.StatefulSetSpec{ServiceName: "redis"},
+		}
+
+		assert.Equal(t, []string{"redis-0.redis"}, statefulSetDNS(statefulSet))
+	})
+}
+
+func TestTemplateFuncs_Owners(t *testing.T) {
+	t.Run("should render pods of a deployment with their owner", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		pod := v1.Pod{ObjectMeta: apiV1.ObjectMeta{Name: "web-1-abc"}, Status: v1.PodStatus{PodIP: "10.0.0.1"}}
+		owner := &unstructured.Unstructured{}
+		owner.SetKind("Deployment")
+		owner.SetName("web")
+		m.EXPECT().PodsOf("deployment", "default", "web").Return(&v1.PodList{Items: []v1.Pod{pod}}, nil)
+		m.EXPECT().Owner(&pod).Return(owner, nil)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ range (podsOf "deployment" "default" "web").Items }}{{ .Status.PodIP }} {{ with ownerOf . }}{{ .GetKind }}/{{ .GetName }}{{ end }}{{ end }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "10.0.0.1 Deployment/web", target.String())
+	})
+}
+
+func TestTemplateFuncs_Lease(t *testing.T) {
+	pods := &v1.PodList{Items: []v1.Pod{
+		{ObjectMeta: apiV1.ObjectMeta{Name: "db-1"}, Status: v1.PodStatus{PodIP: "10.0.0.1"}},
+		{ObjectMeta: apiV1.ObjectMeta{Name: "db-10"}, Status: v1.PodStatus{PodIP: "10.0.0.10"}},
+	}}
+	lease := func(holder *string) *coordinationV1.Lease {
+		transitions := int32(4)
+		return &coordinationV1.Lease{Spec: coordinationV1.LeaseSpec{HolderIdentity: holder, LeaseTransitions: &transitions}}
+	}
+
+	for _, holder := range []string{"db-10", "db-10_6f4c9a2e"} {
+		t.Run("should render IP of the pod holding the lease as "+holder, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mock.NewMockManager(ctrl)
+			m.EXPECT().Lease("default", "db-leader").Return(lease(&holder), nil).Times(2)
+			m.EXPECT().PodsWithLabels("default", "app=db").Return(pods, nil)
+			target := &bytes.Buffer{}
+
+			err := renderTemplate(m, `{{ leaseHolderIP "default" "db-leader" "app=db" }} {{ (lease "default" "db-leader").Spec.LeaseTransitions }}`, target)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "10.0.0.10 4", target.String())
+		})
+	}
+
+	t.Run("should render nothing if the lease has no holder", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Lease("default", "db-leader").Return(lease(nil), nil)
+		m.EXPECT().PodsWithLabels("default", "app=db").Return(pods, nil)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ leaseHolderIP "default" "db-leader" "app=db" }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "", target.String())
+	})
+}
+
+func TestTemplateFuncs_Resources(t *testing.T) {
+	t.Run("should render fields of unstructured objects", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		route := unstructured.Unstructured{}
+		route.SetName("web")
+		route.Object["spec"] = map[string]interface{}{"host": "example.com"}
+		m.EXPECT().Resources("example.com/v1/routes", "", "app=web").Return(&unstructured.UnstructuredList{
+			Items: []unstructured.Unstructured{route},
+		}, nil)
+		m.EXPECT().Resource("example.com/v1/routes", "", "web").Return(&route, nil)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ range (resources "example.com/v1/routes" "" "app=web").Items }}{{ .GetName }} {{ .Object.spec.host }}{{ end }}
+{{ (resource "example.com/v1/routes" "" "web").Object.spec.host }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "web example.com\nexample.com", target.String())
+	})
+
+	t.Run("should not leak values of secrets fetched as resources in errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		secret := unstructured.Unstructured{}
+		secret.SetName("basic-auth")
+		secret.Object["data"] = map[string]interface{}{"password": "aHVudGVyMg=="}
+		m.EXPECT().Resource("v1/secrets", "default", "basic-auth").Return(&secret, nil)
+		m.EXPECT().PodsWithLabels("default", "aHVudGVyMg==").Return(nil, fmt.Errorf("invalid label selector aHVudGVyMg== (hunter2)"))
+
+		err := renderTemplate(m, `{{ (resource "v1/secrets" "default" "basic-auth").Object.data.password | pods "default" }}`, &bytes.Buffer{})
+
+		if assert.Error(t, err) {
+			assert.NotContains(t, err.Error(), "hunter2")
+			assert.NotContains(t, err.Error(), "aHVudGVyMg==")
+			assert.Contains(t, err.Error(), "invalid label selector [redacted] ([redacted])")
+		}
+	})
+}
+
+func TestTemplateFuncs_Readiness(t *testing.T) {
+	t.Run("should render ready addresses with the port of the endpoints", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Endpoints("default", "web").Return(&v1.Endpoints{
+			Subsets: []v1.EndpointSubset{
+				{
+					Addresses:         []v1.EndpointAddress{{IP: "10.0.0.10"}, {IP: "10.0.0.2"}},
+					NotReadyAddresses: []v1.EndpointAddress{{IP: "10.0.0.3"}},
+					Ports:             []v1.EndpointPort{{Name: "http", Port: 8080}},
+				},
+				{
+					Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+					Ports:     []v1.EndpointPort{{Name: "metrics", Port: 9100}},
+				},
+			},
+		}, nil).AnyTimes()
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ $endpoints := endpoints "default" "web" }}{{ $port := portByName "http" $endpoints }}
+{{- range readyAddresses $endpoints }}{{ .IP }}:{{ $port }} {{ end }}
+{{- range notReadyAddresses $endpoints }}{{ .IP }}:{{ $port }} down {{ end }}
+{{- $endpoints | portByName "missing" }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "10.0.0.1:8080 10.0.0.2:8080 10.0.0.10:8080 10.0.0.3:8080 down 0", target.String())
+	})
+
+	t.Run("should render ready and terminating pods", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		deleted := apiV1.Now()
+		ready := []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+		m.EXPECT().PodsWithLabels("default", "app=web").Return(&v1.PodList{Items: []v1.Pod{
+			{ObjectMeta: apiV1.ObjectMeta{Name: "web-c"}, Status: v1.PodStatus{Conditions: ready}},
+			{ObjectMeta: apiV1.ObjectMeta{Name: "web-b", DeletionTimestamp: &deleted}, Status: v1.PodStatus{Conditions: ready}},
+			{ObjectMeta: apiV1.ObjectMeta{Name: "web-a"}, Status: v1.PodStatus{Conditions: ready}},
+			{ObjectMeta: apiV1.ObjectMeta{Name: "web-d"}},
+		}}, nil).AnyTimes()
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ $pods := pods "default" "app=web" }}
+{{- range readyPods $pods }}{{ .Name }} {{ end }}
+{{- range terminatingPods $pods }}{{ .Name }} terminating{{ end }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "web-a web-c web-b terminating", target.String())
+	})
+}
+
+// multiClusterManager serves clusters from mocks, the default one is also in clusters
+type multiClusterManager struct {
+	manager.Manager
+	defaultCluster string
+	clusters       map[string]manager.Manager
+}
+
+func (m multiClusterManager) Cluster(name string) (manager.Manager, error) {
+	cluster, ok := m.clusters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown cluster %q", name)
+	}
+	return cluster, nil
+}
+
+func (m multiClusterManager) Clusters() []string {
+	names := []string{}
+	for name := range m.clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m multiClusterManager) DefaultCluster() string {
+	return m.defaultCluster
+}
+
+func TestTemplateFuncs_Clusters(t *testing.T) {
+	endpoints := func(ip string) *v1.Endpoints {
+		return &v1.Endpoints{
+			ObjectMeta: apiV1.ObjectMeta{UID: types.UID(ip)},
+			Subsets:    []v1.EndpointSubset{{Addresses: []v1.EndpointAddress{{IP: ip}}}},
+		}
+	}
+
+	t.Run("should read from the cluster given as last argument", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		usEast := mock.NewMockManager(ctrl)
+		euWest := mock.NewMockManager(ctrl)
+		m := multiClusterManager{Manager: usEast, defaultCluster: "us-east", clusters: map[string]manager.Manager{"us-east": usEast, "eu-west": euWest}}
+		usEast.EXPECT().Endpoints("default", "api").Return(endpoints("10.0.0.1"), nil).Times(3)
+		euWest.EXPECT().Endpoints("default", "api").Return(endpoints("10.1.0.1"), nil).Times(2)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ range readyAddresses (endpoints "default" "api") }}{{ .IP }}@{{ .Cluster }} {{ end }}
+{{- range clusters }}{{ range readyAddresses (endpoints "default" "api" .) }}{{ .IP }}@{{ .Cluster }} {{ end }}{{ end }}
+{{- range readyAddresses (endpoints "default" "api" "@eu-west") }}{{ .IP }} {{ end }}
+{{- range readyAddresses (endpoints "default" "api" "@us-east") }}{{ .IP }}{{ end }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "10.0.0.1@us-east 10.1.0.1@eu-west 10.0.0.1@us-east 10.1.0.1 10.0.0.1", target.String())
+	})
+
+	t.Run("should not name the cluster of a single cluster", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Endpoints("default", "api").Return(endpoints("10.0.0.1"), nil)
+		m.EXPECT().EndpointSlices("default", "api").Return([]kubernetes.SliceEndpoint{{Addresses: []string{"10.0.0.1"}}}, nil)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ range readyAddresses (endpoints "default" "api") }}{{ .IP }}@{{ .Cluster }} {{ end }}
+{{- range endpointSlices "default" "api" }}{{ index .Addresses 0 }}@{{ .Cluster }}{{ end }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "10.0.0.1@ 10.0.0.1@", target.String())
+	})
+
+	t.Run("should set the cluster of endpoint slices without modifying the ones of the manager", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		euWest := mock.NewMockManager(ctrl)
+		m := multiClusterManager{Manager: mock.NewMockManager(ctrl), defaultCluster: "us-east", clusters: map[string]manager.Manager{"eu-west": euWest}}
+		sliceEndpoints := []kubernetes.SliceEndpoint{{Addresses: []string{"10.1.0.1"}}}
+		euWest.EXPECT().EndpointSlices("default", "api").Return(sliceEndpoints, nil)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ range endpointSlices "default" "api" "@eu-west" }}{{ index .Addresses 0 }}@{{ .Cluster }}{{ end }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "10.1.0.1@eu-west", target.String())
+		assert.Equal(t, "", sliceEndpoints[0].Cluster)
+	})
+
+	t.Run("should keep the cluster of ready pods", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		euWest := mock.NewMockManager(ctrl)
+		m := multiClusterManager{Manager: mock.NewMockManager(ctrl), defaultCluster: "us-east", clusters: map[string]manager.Manager{"eu-west": euWest}}
+		ready := []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+		pod := v1.Pod{
+			ObjectMeta: apiV1.ObjectMeta{Name: "api-a", UID: "a"},
+			Status:     v1.PodStatus{Conditions: ready},
+		}
+		euWest.EXPECT().PodsWithLabels("default", "app=api").Return(&v1.PodList{Items: []v1.Pod{pod}}, nil)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ range readyPods (pods "default" "app=api" "@eu-west") }}{{ .Name }}@{{ clusterOf . }}{{ end }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "api-a@eu-west", target.String())
+	})
+
+	t.Run("should look up the owner of a pod in its cluster", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		euWest := mock.NewMockManager(ctrl)
+		m := multiClusterManager{Manager: mock.NewMockManager(ctrl), defaultCluster: "us-east", clusters: map[string]manager.Manager{"eu-west": euWest}}
+		pod := v1.Pod{ObjectMeta: apiV1.ObjectMeta{Name: "api-a", UID: "a"}}
+		owner := &unstructured.Unstructured{}
+		owner.SetName("api")
+		euWest.EXPECT().PodsWithLabels("default", "app=api").Return(&v1.PodList{Items: []v1.Pod{pod}}, nil)
+		euWest.EXPECT().Owner(&pod).Return(owner, nil)
+		target := &bytes.Buffer{}
+
+		err := renderTemplate(m, `{{ range (pods "default" "app=api" "@eu-west").Items }}{{ (ownerOf .).GetName }}{{ end }}`, target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "api", target.String())
+	})
+
+	t.Run("should return error for unknown clusters", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mock.NewMockManager(ctrl)
+
+		err := renderTemplate(m, `{{ endpoints "default" "api" "@eu-west" }}`, &bytes.Buffer{})
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), `unknown cluster "eu-west"`)
+		}
+	})
+}
+
+func TestSecretValues_Redact(t *testing.T) {
+	t.Run("should redact values containing other values entirely", func(t *testing.T) {
+		secrets := &secretValues{}
+		secrets.add("pass")
+		secrets.add("password")
+		secrets.add("")
+
+		assert.Equal(t, "[redacted] and [redacted]", secrets.redact("password and pass"))
+	})
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/thecasualcoder/kube-template/pkg/manager"
+	"strings"
+	"time"
+)
+
+// runOnce writes every template a single time through renderEach.
+func runOnce(ctx context.Context, m manager.Manager, templateArgs []templateArg, timeout time.Duration) error {
+	return renderEach(ctx, m, templateArgs, timeout, writeTemplate)
+}
+
+// renderEach renders every template a single time as soon as all the data it needs is available
+// and passes the render to handle.
+// If timeout is not zero and elapses first, the keys of watches which are still waiting
+// for data are returned as an error.
+func renderEach(
+	ctx context.Context,
+	m manager.Manager,
+	templateArgs []templateArg,
+	timeout time.Duration,
+	handle func(templateArg templateArg, buf *bytes.Buffer) error,
+) error {
+	var timeoutChan <-chan time.Time
+	if timeout > 0 {
+		timeoutChan = time.After(timeout)
+	}
+
+	rendered := make([]bool, len(templateArgs))
+	pending := len(templateArgs)
+
+	for {
+		for i, templateArg := range templateArgs {
+			if rendered[i] {
+				continue
+			}
+
+			buf := &bytes.Buffer{}
+			if err := renderTemplate(m, templateArg.source, buf); err == manager.ErrDataNotReady {
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			if err := handle(templateArg, buf); err != nil {
+				return err
+			}
+			rendered[i] = true
+			pending--
+		}
+
+		if pending == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting for data: %s", pendingKeysMessage(m.PendingKeys()))
+		case err := <-m.ErrorChan():
+			return err
+		case <-m.EventChan():
+		case <-timeoutChan:
+			return fmt.Errorf("timed out after %s waiting for data: %s", timeout, pendingKeysMessage(m.PendingKeys()))
+		}
+	}
+}
+
+func pendingKeysMessage(keys []string) string {
+	if len(keys) == 0 {
+		return "no watch has been started"
+	}
+	return fmt.Sprintf("%s not ready", strings.Join(keys, ", "))
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecasualcoder/kube-template/mock"
+	"github.com/thecasualcoder/kube-template/pkg/manager"
+	v1 "k8s.io/api/core/v1"
+	"testing"
+	"time"
+)
+
+func TestRunOnce(t *testing.T) {
+	source := `{{ with endpoints "default" "nginx" }}{{ range .Subsets }}{{ range .Addresses }}{{ .IP }}{{ end }}{{ end }}{{ end }}`
+	endpoints := &v1.Endpoints{
+		Subsets: []v1.EndpointSubset{
+			{Addresses: []v1.EndpointAddress{{IP: "10.0.0.100"}}},
+		},
+	}
+
+	t.Run("should write the template once data is ready", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		fs := afero.NewMemMapFs()
+		target := target{fs: fs, path: "nginx.conf", uid: -1, gid: -1}
+		eventChan := make(chan struct{}, 1)
+		eventChan <- struct{}{}
+
+		m := mock.NewMockManager(ctrl)
+		gomock.InOrder(
+			m.EXPECT().Endpoints("default", "nginx").Return(nil, manager.ErrDataNotReady),
+			m.EXPECT().Endpoints("default", "nginx").Return(endpoints, nil),
+		)
+		m.EXPECT().EventChan().Return(eventChan).AnyTimes()
+		m.EXPECT().ErrorChan().Return(make(chan error)).AnyTimes()
+
+		err := runOnce(context.Background(), m, []templateArg{{source: source, target: target}}, time.Second)
+
+		assert.NoError(t, err)
+		contents, _ := afero.ReadFile(fs, "nginx.conf")
+		assert.Equal(t, "10.0.0.100", string(contents))
+	})
+
+	t.Run("should return pending keys if data is not ready before timeout", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		fs := afero.NewMemMapFs()
+		target := target{fs: fs, path: "nginx.conf", uid: -1, gid: -1}
+
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Endpoints("default", "nginx").Return(nil, manager.ErrDataNotReady)
+		m.EXPECT().EventChan().Return(make(chan struct{})).AnyTimes()
+		m.EXPECT().ErrorChan().Return(make(chan error)).AnyTimes()
+		m.EXPECT().PendingKeys().Return([]string{"endpoints/default/nginx"})
+
+		err := runOnce(context.Background(), m, []templateArg{{source: source, target: target}}, 10*time.Millisecond)
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "endpoints/default/nginx not ready")
+		}
+	})
+
+	t.Run("should return pending keys once the context is done", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		fs := afero.NewMemMapFs()
+		target := target{fs: fs, path: "nginx.conf", uid: -1, gid: -1}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Endpoints("default", "nginx").Return(nil, manager.ErrDataNotReady)
+		m.EXPECT().EventChan().Return(make(chan struct{})).AnyTimes()
+		m.EXPECT().ErrorChan().Return(make(chan error)).AnyTimes()
+		m.EXPECT().PendingKeys().Return([]string{"endpoints/default/nginx"})
+
+		err := runOnce(ctx, m, []templateArg{{source: source, target: target}}, 0)
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "stopped waiting for data")
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"github.com/thecasualcoder/kube-template/pkg/logger"
+	"github.com/thecasualcoder/kube-template/pkg/manager"
+	"time"
+)
+
+// templateRenderer renders a single template in the background
+// whenever it is notified of changes to resources or to its source.
+type templateRenderer struct {
+	manager     manager.Manager
+	index       int
+	templateArg templateArg
+	eventChan   chan struct{}
+	sourceChan  chan string
+	writtenChan chan<- int
+	errChan     chan<- error
+}
+
+func newTemplateRenderer(
+	m manager.Manager,
+	index int,
+	templateArg templateArg,
+	writtenChan chan<- int,
+	errChan chan<- error,
+) *templateRenderer {
+	return &templateRenderer{
+		manager:     m,
+		index:       index,
+		templateArg: templateArg,
+		eventChan:   make(chan struct{}, 1),
+		sourceChan:  make(chan string, 1),
+		writtenChan: writtenChan,
+		errChan:     errChan,
+	}
+}
+
+// notify the renderer that resources have changed.
+// The event is dropped if the renderer already has one pending.
+func (r *templateRenderer) notify() {
+	select {
+	case r.eventChan <- struct{}{}:
+	default:
+	}
+}
+
+// reload replaces the source of the template and renders it again.
+// A pending source which was not picked up yet is replaced.
+// It never blocks and can be called from multiple goroutines.
+func (r *templateRenderer) reload(source string) {
+	for {
+		select {
+		case r.sourceChan <- source:
+			return
+		default:
+		}
+
+		select {
+		case <-r.sourceChan:
+		default:
+		}
+	}
+}
+
+// run re-renders the template whenever an event arrives
+// and writes it to the target once no events have arrived for templateArg.wait.min,
+// or once templateArg.wait.max has passed since the first event which was not written yet.
+// Writes whose contents are the same as the last write are skipped.
+// The index of the template is sent on writtenChan after every write.
+// A pending write is flushed once ctx is done.
+func (r *templateRenderer) run(ctx context.Context) {
+	source := r.templateArg.source
+	wait := r.templateArg.wait
+	path := r.templateArg.target.path
+
+	buf := &bytes.Buffer{}
+	minTimer := newStoppedTimer()
+	maxTimer := newStoppedTimer()
+	maxTimerRunning := false
+
+	written := false
+	var lastWrittenHash [sha256.Size]byte
+	skipped := 0
+
+	// write returns false if the write was skipped
+	write := func() (bool, error) {
+		hash := sha256.Sum256(buf.Bytes())
+		if written && hash == lastWrittenHash {
+			skipped++
+			logger.Debugf("contents of %s have not changed, skipping write (%d skipped)", path, skipped)
+			buf.Reset()
+			return false, nil
+		}
+
+		if err := writeTemplate(r.templateArg, buf); err != nil {
+			return false, err
+		}
+		written = true
+		lastWrittenHash = hash
+		return true, nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if maxTimerRunning && buf.Len() != 0 {
+				logger.Infof("flushing pending write of %s", path)
+				if _, err := write(); err != nil {
+					logger.Errorf("%v", err)
+				}
+			}
+			return
+		case source = <-r.sourceChan:
+			logger.Infof("source of %s reloaded", path)
+			r.notify()
+			continue
+		case <-r.eventChan:
+			buf.Reset()
+			if err := renderTemplate(r.manager, source, buf); err != nil {
+				if err == manager.ErrDataNotReady {
+					buf.Reset()
+					continue
+				}
+				r.fail(ctx, err)
+				return
+			}
+			resetTimer(minTimer, wait.min)
+			if !maxTimerRunning {
+				resetTimer(maxTimer, wait.max)
+				maxTimerRunning = true
+			}
+			continue
+		case <-minTimer.C:
+			stopTimer(maxTimer)
+		case <-maxTimer.C:
+			logger.Debugf("changes to %s did not settle within %s, writing it", path, wait.max)
+			stopTimer(minTimer)
+		}
+		maxTimerRunning = false
+
+		if buf.Len() == 0 {
+			continue
+		}
+
+		wrote, err := write()
+		if err != nil {
+			r.fail(ctx, err)
+			return
+		}
+		if !wrote {
+			continue
+		}
+
+		select {
+		case r.writtenChan <- r.index:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// fail reports the error unless kube-template is already shutting down
+func (r *templateRenderer) fail(ctx context.Context, err error) {
+	select {
+	case r.errChan <- err:
+	case <-ctx.Done():
+		logger.Errorf("%v", err)
+	}
+}
+
+// writeTemplate replaces the contents of the target with buf
+// and runs the command of the template if it has one.
+// Failure of the command returns an error only if its failure policy is stop.
+func writeTemplate(templateArg templateArg, buf *bytes.Buffer) error {
+	if err := templateArg.target.write(buf.Bytes()); err != nil {
+		return err
+	}
+	buf.Reset()
+
+	if templateArg.command != nil {
+		return templateArg.command.run()
+	}
+	return nil
+}
+
+func newStoppedTimer() *time.Timer {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	return timer
+}
+
+// stopTimer stops the timer and drains its channel if it had already fired
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
+
+func resetTimer(timer *time.Timer, duration time.Duration) {
+	stopTimer(timer)
+	timer.Reset(duration)
+}
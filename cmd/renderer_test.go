@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecasualcoder/kube-template/mock"
+	v1 "k8s.io/api/core/v1"
+	apiV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestTemplateRenderer_Run(t *testing.T) {
+	t.Run("should skip writes when contents have not changed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		fs := afero.NewMemMapFs()
+		templateArg := templateArg{
+			source: `{{ with endpoints "default" "nginx" }}{{ .Name }}{{ end }}`,
+			target: target{fs: fs, path: "nginx.conf", uid: -1, gid: -1},
+			wait:   waitWindow{min: 10 * time.Millisecond, max: 40 * time.Millisecond},
+		}
+		writtenChan := make(chan int, 10)
+		errChan := make(chan error, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		m := mock.NewMockManager(ctrl)
+		gomock.InOrder(
+			m.EXPECT().Endpoints("default", "nginx").Return(&v1.Endpoints{ObjectMeta: apiV1.ObjectMeta{Name: "v1"}}, nil).Times(2),
+			m.EXPECT().Endpoints("default", "nginx").Return(&v1.Endpoints{ObjectMeta: apiV1.ObjectMeta{Name: "v2"}}, nil),
+		)
+
+		r := newTemplateRenderer(m, 0, templateArg, writtenChan, errChan)
+		go r.run(ctx)
+
+		for i := 0; i < 3; i++ {
+			r.notify()
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		assert.Len(t, writtenChan, 2)
+		assert.Len(t, errChan, 0)
+		contents, _ := afero.ReadFile(fs, "nginx.conf")
+		assert.Equal(t, "v2", string(contents))
+	})
+
+	t.Run("should write once max wait has passed even if events keep arriving", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		fs := afero.NewMemMapFs()
+		templateArg := templateArg{
+			source: `{{ with endpoints "default" "nginx" }}{{ .Name }}{{ end }}`,
+			target: target{fs: fs, path: "nginx.conf", uid: -1, gid: -1},
+			wait:   waitWindow{min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		}
+		writtenChan := make(chan int, 10)
+		errChan := make(chan error, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Endpoints("default", "nginx").Return(&v1.Endpoints{ObjectMeta: apiV1.ObjectMeta{Name: "v1"}}, nil).AnyTimes()
+
+		r := newTemplateRenderer(m, 0, templateArg, writtenChan, errChan)
+		go r.run(ctx)
+
+		deadline := time.Now().Add(300 * time.Millisecond)
+		for time.Now().Before(deadline) && len(writtenChan) == 0 {
+			r.notify()
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		assert.Len(t, writtenChan, 1)
+		contents, _ := afero.ReadFile(fs, "nginx.conf")
+		assert.Equal(t, "v1", string(contents))
+	})
+
+	t.Run("should render the new source once it is reloaded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		fs := afero.NewMemMapFs()
+		templateArg := templateArg{
+			source: `{{ with endpoints "default" "nginx" }}{{ .Name }}{{ end }}`,
+			target: target{fs: fs, path: "nginx.conf", uid: -1, gid: -1},
+			wait:   waitWindow{min: 10 * time.Millisecond, max: 40 * time.Millisecond},
+		}
+		writtenChan := make(chan int, 10)
+		errChan := make(chan error, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Endpoints("default", "nginx").Return(&v1.Endpoints{ObjectMeta: apiV1.ObjectMeta{Name: "v1"}}, nil).AnyTimes()
+
+		r := newTemplateRenderer(m, 0, templateArg, writtenChan, errChan)
+		go r.run(ctx)
+
+		r.notify()
+		time.Sleep(50 * time.Millisecond)
+		r.reload(`name: {{ with endpoints "default" "nginx" }}{{ .Name }}{{ end }}`)
+		time.Sleep(50 * time.Millisecond)
+
+		assert.Len(t, writtenChan, 2)
+		contents, _ := afero.ReadFile(fs, "nginx.conf")
+		assert.Equal(t, "name: v1", string(contents))
+	})
+
+	t.Run("should flush a pending write once the context is done", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		fs := afero.NewMemMapFs()
+		templateArg := templateArg{
+			source: `{{ with endpoints "default" "nginx" }}{{ .Name }}{{ end }}`,
+			target: target{fs: fs, path: "nginx.conf", uid: -1, gid: -1},
+			wait:   waitWindow{min: time.Hour, max: time.Hour},
+		}
+		writtenChan := make(chan int, 10)
+		errChan := make(chan error, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		m := mock.NewMockManager(ctrl)
+		m.EXPECT().Endpoints("default", "nginx").Return(&v1.Endpoints{ObjectMeta: apiV1.ObjectMeta{Name: "v1"}}, nil)
+
+		r := newTemplateRenderer(m, 0, templateArg, writtenChan, errChan)
+		done := make(chan struct{})
+		go func() {
+			r.run(ctx)
+			close(done)
+		}()
+
+		r.notify()
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		<-done
+
+		assert.Len(t, errChan, 0)
+		contents, _ := afero.ReadFile(fs, "nginx.conf")
+		assert.Equal(t, "v1", string(contents))
+	})
+}
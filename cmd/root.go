@@ -1,17 +1,19 @@
 package cmd
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"github.com/mitchellh/go-homedir"
 	"github.com/thecasualcoder/kube-template/pkg/kubernetes"
+	"github.com/thecasualcoder/kube-template/pkg/logger"
 	"github.com/thecasualcoder/kube-template/pkg/manager"
 	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
 	"text/template"
 	"time"
 
@@ -20,8 +22,35 @@ import (
 )
 
 const (
-	kubeConfigFlag = "kubeconfig"
-	templateFlag   = "template"
+	kubeConfigFlag     = "kubeconfig"
+	templateFlag       = "template"
+	templateStringFlag = "template-string"
+	configFlag         = "config"
+	contextFlag        = "context"
+	namespaceFlag      = "namespace"
+	inClusterFlag      = "in-cluster"
+	asFlag             = "as"
+	asGroupFlag        = "as-group"
+	clusterFlag        = "cluster"
+	logLevelFlag       = "log-level"
+	onceFlag           = "once"
+	onceTimeoutFlag    = "once-timeout"
+	execFlag           = "exec"
+	execReloadFlag     = "exec-reload-signal"
+	execKillFlag       = "exec-kill-signal"
+	execTimeoutFlag    = "exec-kill-timeout"
+	overwriteFlag      = "overwrite"
+	keepOnExitFlag     = "keep-on-exit"
+	backupsFlag        = "backups"
+	waitFlag           = "wait"
+	watchTemplatesFlag = "watch-templates"
+	dryRunFlag         = "dry-run"
+)
+
+const (
+	defaultMinWait     = 2 * time.Second
+	defaultMaxWait     = 4 * defaultMinWait
+	defaultKillTimeout = 5 * time.Second
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -35,66 +64,164 @@ var rootCmd = &cobra.Command{
 			return fmt.Errorf("kube-tempalte does not accept args")
 		}
 
-		templateFlag, _ := cmd.Flags().GetString(templateFlag)
-		kubeconfig, _ := cmd.Flags().GetString(kubeConfigFlag)
-
-		fs := afero.NewOsFs()
+		fs := newOsFs()
 
-		templateArg, err := newTemplateArg(fs, templateFlag)
+		cfg, err := loadConfig(fs, cmd)
 		if err != nil {
 			_ = cmd.Help()
 			return err
 		}
-		defer func() {
-			_ = templateArg.target.Close()
-			_ = fs.Remove(templateArg.target.Name())
-		}()
 
-		const DefaultFileContentWriteTimeout = 2
+		if cfg.Log != nil && cfg.Log.Level != "" {
+			level, err := logger.ParseLevel(cfg.Log.Level)
+			if err != nil {
+				return err
+			}
+			logger.SetLevel(level)
+		}
+
+		defaultWait, err := parseWait(cfg.Wait, waitWindow{min: defaultMinWait, max: defaultMaxWait})
+		if err != nil {
+			return fmt.Errorf("invalid wait: %w", err)
+		}
+
+		once, _ := cmd.Flags().GetBool(onceFlag)
+		onceTimeout, _ := cmd.Flags().GetDuration(onceTimeoutFlag)
+		dryRun, _ := cmd.Flags().GetBool(dryRunFlag)
+		if once && cfg.Exec != nil {
+			return fmt.Errorf("exec cannot be used along with --%s", onceFlag)
+		}
+		if once && dryRun {
+			return fmt.Errorf("--%s cannot be used along with --%s", dryRunFlag, onceFlag)
+		}
+
+		var execCmd *execCommand
+		if cfg.Exec != nil {
+			command, err := newExecCommand(cfg.Exec)
+			if err != nil {
+				return err
+			}
+			// a dry run only validates the exec config
+			if !dryRun {
+				execCmd = &command
+			}
+		}
+
+		// a dry run only reads targets, so existing ones are expected
+		if dryRun {
+			for _, templateConfig := range cfg.Templates {
+				templateConfig.Overwrite = true
+			}
+		}
+
+		templateArgs, err := newTemplateArgs(fs, cfg.Templates, defaultWait)
+		if err != nil {
+			_ = cmd.Help()
+			return err
+		}
+		// targets rendered in once mode are meant to be consumed after kube-template exits
+		if !once && !dryRun {
+			defer removeTargets(templateArgs)
+		}
 
-		return run(templateArg, kubeconfig, time.Duration(DefaultFileContentWriteTimeout))
+		clientConfig := kubernetes.Config{
+			Kubeconfig: cfg.Kubeconfig,
+			Context:    cfg.Context,
+			Namespace:  cfg.Namespace,
+			InCluster:  cfg.InCluster,
+			As:         cfg.As,
+			AsGroups:   cfg.AsGroups,
+		}
+		return run(runConfig{
+			fs:             fs,
+			templateArgs:   templateArgs,
+			watchTemplates: cfg.WatchTemplates,
+			execCommand:    execCmd,
+			clientConfig:   clientConfig,
+			clusterConfigs: newClusterConfigs(clientConfig, cfg.Clusters),
+			once:           once,
+			onceTimeout:    onceTimeout,
+			dryRun:         dryRun,
+		})
 	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	rootCmd.Flags().StringP(templateFlag, "t", "", "template to render. Should be of the format \"/path/to/template.tmpl:/path/to/rendered.conf\". \"-\" in target means STDOUT")
+	rootCmd.Flags().StringArrayP(templateFlag, "t", nil, "template to render. Should be of the format \"/path/to/template.tmpl:/path/to/rendered.conf\" or \"source=/path/to/template.tmpl,destination=/path/to/rendered.conf,perms=0640,command=...\" with the keys of templates in the config file. \"-\" in target means STDOUT. Can be repeated to render multiple templates")
+	rootCmd.Flags().StringArray(templateStringFlag, nil, "inline template to render. Should be of the format \"{{ template }}:/path/to/rendered.conf\". \"-\" in target means STDOUT. Can be repeated")
+	rootCmd.Flags().StringP(configFlag, "c", "", "(optional) path to a YAML or HCL (.hcl) config file. Flags override values from the file")
+	rootCmd.Flags().String(kubeConfigFlag, "", "(optional) absolute path to the kubeconfig file. Defaults to $KUBECONFIG or ~/.kube/config, falling back to the in-cluster config if neither exist")
+	rootCmd.Flags().String(contextFlag, "", "(optional) kubeconfig context to use. Defaults to the current context")
+	rootCmd.Flags().StringP(namespaceFlag, "n", "", "(optional) namespace used by template functions when their namespace is empty. Defaults to the namespace of the context or of the pod when running in a cluster")
+	rootCmd.Flags().Bool(inClusterFlag, false, "(optional) use the service account of the pod instead of a kubeconfig")
+	rootCmd.Flags().String(asFlag, "", "(optional) user to impersonate")
+	rootCmd.Flags().StringArray(asGroupFlag, nil, "(optional) group to impersonate. Requires --as. Can be repeated")
+	rootCmd.Flags().StringArray(clusterFlag, nil, "(optional) another cluster queried by templates, as \"name=context\" with a kubeconfig context. Template functions read from it when given \"@name\" as their last argument. The context of the default cluster names it instead. Can be repeated")
+	rootCmd.Flags().String(logLevelFlag, "info", "(optional) log level. One of debug, info, warn or error")
+	rootCmd.Flags().String(execFlag, "", "(optional) command to run once every template is written. Arguments are split like a shell does")
+	rootCmd.Flags().String(execReloadFlag, "", "(optional) signal sent to the exec process when a template changes. The process is restarted if empty")
+	rootCmd.Flags().String(execKillFlag, "SIGINT", "(optional) signal sent to the exec process to stop it")
+	rootCmd.Flags().Duration(execTimeoutFlag, defaultKillTimeout, "(optional) time to wait for the exec process to stop before killing it")
+	rootCmd.Flags().String(waitFlag, "", "(optional) default wait of templates as \"min\" or \"min:max\". A template is written once there are no changes for min, but never later than max. Defaults to \"2s:8s\"")
+	rootCmd.Flags().Bool(watchTemplatesFlag, false, "(optional) re-render templates whenever their source files change")
+	rootCmd.Flags().Bool(overwriteFlag, false, "(optional) replace targets which already exist when kube-template starts")
+	rootCmd.Flags().Bool(keepOnExitFlag, false, "(optional) keep targets when kube-template exits instead of removing them")
+	rootCmd.Flags().Int(backupsFlag, 0, "(optional) number of previous versions of targets to keep as <target>.bak, <target>.bak.1 and so on")
+	rootCmd.Flags().Bool(onceFlag, false, "(optional) render every template once all its data is available, keep the targets and exit")
+	rootCmd.Flags().Duration(onceTimeoutFlag, 0, "(optional) fail if data for --once or --dry-run is not available within this duration. 0 waits forever")
+	rootCmd.Flags().Bool(dryRunFlag, false, "(optional) print a diff between every target and its render instead of writing it and exit. Exits with 2 if any target would change")
 
-	kubeconfig := os.Getenv("KUBECONFIG")
+	if err := rootCmd.Execute(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
 
-	if kubeconfig == "" {
-		home, err := homedir.Dir()
-		if err == nil {
-			kubeconfig = filepath.Join(home, ".kube", "config")
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
 		}
+		os.Exit(1)
 	}
+}
+
+func run(rc runConfig) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	rootCmd.Flags().String(kubeConfigFlag, kubeconfig, "(optional) absolute path to the kubeconfig file")
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signalChan)
 
-	err := rootCmd.MarkFlagRequired(templateFlag)
+	client, err := kubernetes.NewClient(rc.clientConfig)
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		return fmt.Errorf("error creating kube-client: %w", err)
 	}
 
-	if err := rootCmd.Execute(); err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	defaultCluster, err := defaultClusterName(client.Context(), rc.clusterConfigs)
+	if err != nil {
+		return err
 	}
-}
 
-func run(
-	templateArg templateArg,
-	kubeconfig string,
-	filecontentWriteTimeout time.Duration,
-) error {
-	client, err := kubernetes.NewClient(kubeconfig)
-	if err != nil {
-		return fmt.Errorf("error creating kube-client: %w", err)
+	clusterClients := map[string]kubernetes.Client{}
+	for name, clusterConfig := range rc.clusterConfigs {
+		// a cluster using the context of the default cluster only names it
+		if clusterConfig.Context == client.Context() {
+			continue
+		}
+
+		clusterClient, err := kubernetes.NewClient(clusterConfig)
+		if err != nil {
+			return fmt.Errorf("error creating kube-client for cluster %s: %w", name, err)
+		}
+		clusterClients[name] = clusterClient
 	}
 
-	m := manager.New(client)
+	m, err := manager.NewMultiCluster(ctx, defaultCluster, client, clusterClients)
+	if err != nil {
+		return err
+	}
+	templateArgs := rc.templateArgs
+	execCmd := rc.execCommand
 
 	// render the templates first.
 	// This solves 2 purposes:
@@ -102,109 +229,171 @@ func run(
 	//		2. start pre-fetch of data needed for templates
 	//
 	// Any errors that happen during data fetch will not be captured here.
-	if err = renderTemplate(m, templateArg.source, ioutil.Discard); err != nil && err != manager.ErrDataNotReady {
-		return fmt.Errorf("error rendering template: %v", err)
+	for _, templateArg := range templateArgs {
+		if err = renderTemplate(m, templateArg.source, ioutil.Discard); err != nil && err != manager.ErrDataNotReady {
+			return fmt.Errorf("error rendering template: %v", err)
+		}
+	}
+
+	if rc.once || rc.dryRun {
+		go func() {
+			for sig := range signalChan {
+				if sig != syscall.SIGHUP {
+					logger.Infof("received %s, stopping", sig)
+					cancel()
+					return
+				}
+			}
+		}()
+		if rc.dryRun {
+			return runDryRun(ctx, m, templateArgs, rc.onceTimeout, os.Stdout)
+		}
+		return runOnce(ctx, m, templateArgs, rc.onceTimeout)
 	}
 
 	errChan := make(chan error)
-	go func() {
-		buf := &bytes.Buffer{}
-		duration := filecontentWriteTimeout * time.Second
+	writtenChan := make(chan int)
+	renderers := make([]*templateRenderer, 0, len(templateArgs))
+	renderersDone := &sync.WaitGroup{}
+	for i, templateArg := range templateArgs {
+		renderer := newTemplateRenderer(m, i, templateArg, writtenChan, errChan)
+		renderers = append(renderers, renderer)
+
+		renderersDone.Add(1)
+		go func() {
+			defer renderersDone.Done()
+			renderer.run(ctx)
+		}()
+	}
+
+	if rc.watchTemplates {
+		sources, err := newSourceWatcher(rc.fs, m, renderers)
+		if err != nil {
+			return err
+		}
+		go sources.run(ctx)
+	}
 
+	go func() {
 		for {
-			timer := time.NewTimer(duration)
 			select {
+			case <-ctx.Done():
+				return
 			case err := <-m.ErrorChan():
-				errChan <- err
+				select {
+				case errChan <- err:
+				case <-ctx.Done():
+				}
 				return
 			case <-m.EventChan():
-				buf.Reset()
-				if err = renderTemplate(m, templateArg.source, buf); err != nil {
-					if err == manager.ErrDataNotReady {
-						buf.Reset()
-						continue
-					}
-					errChan <- err
-					return
-				}
-				timer.Reset(duration)
-			case <-timer.C:
-				if buf.Len() == 0 {
-					continue
-				}
-				if err := resetFileContent(templateArg.target); err != nil {
-					errChan <- err
-					return
+				for _, renderer := range renderers {
+					renderer.notify()
 				}
+			}
+		}
+	}()
 
-				if _, err := buf.WriteTo(templateArg.target); err != nil {
-					errChan <- err
-					return
+	var child *childProcess
+	written := make(map[int]bool)
+	for {
+		var childExited <-chan struct{}
+		if child != nil {
+			childExited = child.exited
+		}
+
+		select {
+		case err := <-errChan:
+			cancel()
+			if child != nil {
+				if stopErr := child.stop(execCmd.killSignal); stopErr != nil {
+					logger.Errorf("error stopping process %s: %v", execCmd.command, stopErr)
 				}
+			}
+			return err
+		case sig := <-signalChan:
+			if sig == syscall.SIGHUP {
+				logger.Infof("received %s, reloading templates", sig)
+				reloadTemplates(rc.fs, m, renderers)
+				continue
+			}
 
-				buf.Reset()
+			logger.Infof("received %s, stopping", sig)
+			// stop watches and flush pending writes
+			cancel()
+			renderersDone.Wait()
+
+			if child == nil {
+				return nil
+			}
+			// forward the signal and exit along with the child
+			if err := child.stop(sig); err != nil {
+				return fmt.Errorf("error stopping process %s: %w", execCmd.command, err)
+			}
+			return child.exitError()
+		case <-childExited:
+			cancel()
+			return child.exitError()
+		case i := <-writtenChan:
+			written[i] = true
+			if execCmd == nil || len(written) != len(templateArgs) {
+				continue
 			}
-		}
-	}()
 
-	return <-errChan
-}
+			// the child process is started once every template is written
+			// and reloaded whenever any of them changes afterwards
+			if child == nil {
+				child = newChildProcess(*execCmd)
+				if err := child.start(); err != nil {
+					return err
+				}
+				continue
+			}
 
-func resetFileContent(file afero.File) error {
-	err := file.Truncate(0)
-	if err != nil {
-		return fmt.Errorf("error when truncating file %s: %w", file.Name(), err)
-	}
-	_, err = file.Seek(0, 0)
-	if err != nil {
-		return fmt.Errorf("error when seeking to start of file %s: %w", file.Name(), err)
+			if err := child.reload(); err != nil {
+				return fmt.Errorf("error reloading process %s: %w", execCmd.command, err)
+			}
+		}
 	}
-	return nil
 }
 
-func killCommandIfRunning(command *exec.Cmd) error {
-	if command.ProcessState != nil && !command.ProcessState.Exited() {
-		err := command.Process.Signal(os.Interrupt)
-		if err != nil {
-			return err
+// reloadTemplates reads the sources of all templates which have one again from disk.
+// A source which cannot be read or is not a valid template is reported
+// and its renderer keeps rendering the previous source.
+func reloadTemplates(fs afero.Fs, m manager.Manager, renderers []*templateRenderer) {
+	for _, renderer := range renderers {
+		if !renderer.templateArg.hasSourceFile() {
+			continue
 		}
+		sourcePath := renderer.templateArg.sourcePath
 
-		const defaultInterruptWaitTimeout = 5
-		timeOutCh := time.After(defaultInterruptWaitTimeout * time.Second)
-		checkTimeOutCh := time.After(500 * time.Millisecond)
-		for {
-			select {
-			case <-timeOutCh:
-				return command.Process.Kill()
-			case <-checkTimeOutCh:
-				if command.ProcessState == nil || command.ProcessState.Exited() {
-					return nil
-				}
-				checkTimeOutCh = time.After(500 * time.Millisecond)
-			}
+		source, err := readTemplate(fs, m, sourcePath)
+		if err != nil {
+			logger.Errorf("error reloading template %s, keeping the previous one: %v", sourcePath, err)
+			continue
 		}
-	}
 
-	return nil
+		renderer.reload(source)
+	}
 }
 
-func execute(command *exec.Cmd) error {
-	if err := command.Start(); err != nil {
-		return fmt.Errorf("error starting process %s: %v", command.Path, err)
+// readTemplate reads the source of a template and checks that it is a valid template
+func readTemplate(fs afero.Fs, m manager.Manager, path string) (string, error) {
+	source, err := getSourceContents(fs, path)
+	if err != nil {
+		return "", err
 	}
-	go func() {
-		if err := command.Wait(); err != nil {
-			_, _ = fmt.Fprintln(os.Stderr, err)
-		}
-	}()
-	return nil
+
+	if err := renderTemplate(m, source, ioutil.Discard); err != nil && err != manager.ErrDataNotReady {
+		return "", err
+	}
+	return source, nil
 }
 
+// renderTemplate renders source to target.
+// Values of secrets read by the template are redacted from the returned error.
 func renderTemplate(m manager.Manager, source string, target io.Writer) error {
-	tmpl := template.New("").Funcs(template.FuncMap{
-		"endpoints": m.Endpoints,
-		"pods":      m.PodsWithLabels,
-	})
+	secrets := &secretValues{}
+	tmpl := template.New("").Funcs(templateFuncs(m, secrets))
 
 	tmpl, err := tmpl.Parse(source)
 	if err != nil {
@@ -216,7 +405,7 @@ func renderTemplate(m manager.Manager, source string, target io.Writer) error {
 		if strings.Contains(err.Error(), manager.ErrDataNotReady.Error()) {
 			return manager.ErrDataNotReady
 		}
-		return fmt.Errorf("error rendering template: %w", err)
+		return fmt.Errorf("error rendering template: %s", secrets.redact(err.Error()))
 	}
 	return nil
 }
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"github.com/thecasualcoder/kube-template/pkg/logger"
+	"github.com/thecasualcoder/kube-template/pkg/manager"
+	"path/filepath"
+)
+
+// sourceWatcher reloads templates whenever their sources change on disk.
+// Inline templates and templates read from STDIN are not watched.
+// The directories of the sources are watched instead of the files themselves,
+// since kubernetes updates ConfigMap volumes by swapping a symlink,
+// which replaces the files without ever writing to them.
+type sourceWatcher struct {
+	fs      afero.Fs
+	manager manager.Manager
+	watcher *fsnotify.Watcher
+	// renderers by the directory of their source
+	renderers map[string][]*templateRenderer
+	// last good source of every renderer
+	sources map[*templateRenderer]string
+}
+
+func newSourceWatcher(fs afero.Fs, m manager.Manager, renderers []*templateRenderer) (*sourceWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("error watching template sources: %w", err)
+	}
+
+	w := &sourceWatcher{
+		fs:        fs,
+		manager:   m,
+		watcher:   watcher,
+		renderers: make(map[string][]*templateRenderer),
+		sources:   make(map[*templateRenderer]string),
+	}
+
+	for _, renderer := range renderers {
+		if !renderer.templateArg.hasSourceFile() {
+			continue
+		}
+		path := renderer.templateArg.sourcePath
+		dir := filepath.Dir(path)
+
+		if _, ok := w.renderers[dir]; !ok {
+			if err := watcher.Add(dir); err != nil {
+				_ = watcher.Close()
+				return nil, fmt.Errorf("error watching directory %s of template %s: %w", dir, path, err)
+			}
+		}
+		w.renderers[dir] = append(w.renderers[dir], renderer)
+		w.sources[renderer] = renderer.templateArg.source
+	}
+
+	return w, nil
+}
+
+// run reloads templates on changes to their directories until ctx is done
+func (w *sourceWatcher) run(ctx context.Context) {
+	defer func() {
+		_ = w.watcher.Close()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			logger.Debugf("template source directory changed: %s", event)
+			w.reload(filepath.Dir(event.Name))
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Errorf("error watching template sources: %v", err)
+		}
+	}
+}
+
+// reload the templates in dir whose sources have changed.
+// A source which cannot be read or is not a valid template is reported
+// and the last good one keeps being rendered.
+func (w *sourceWatcher) reload(dir string) {
+	for _, renderer := range w.renderers[dir] {
+		path := renderer.templateArg.sourcePath
+
+		source, err := readTemplate(w.fs, w.manager, path)
+		if err != nil {
+			logger.Errorf("error reloading template %s, keeping the previous one: %v", path, err)
+			continue
+		}
+
+		if source == w.sources[renderer] {
+			continue
+		}
+		w.sources[renderer] = source
+		renderer.reload(source)
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecasualcoder/kube-template/mock"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSourceWatcher(t *testing.T) {
+	// newWatchedRenderer starts watching the source at path and returns the renderer of it
+	newWatchedRenderer := func(t *testing.T, ctx context.Context, path string) *templateRenderer {
+		ctrl := gomock.NewController(t)
+		m := mock.NewMockManager(ctrl)
+		fs := afero.NewOsFs()
+
+		source, err := getSourceContents(fs, path)
+		assert.NoError(t, err)
+		renderer := newTemplateRenderer(m, 0, templateArg{sourcePath: path, source: source}, nil, nil)
+
+		w, err := newSourceWatcher(fs, m, []*templateRenderer{renderer})
+		if assert.NoError(t, err) {
+			go w.run(ctx)
+		}
+		return renderer
+	}
+
+	// reloaded waits for the renderer to be reloaded with source.
+	// Sources can be reloaded while they are being written, so earlier ones are skipped.
+	reloaded := func(renderer *templateRenderer, source string) bool {
+		timeout := time.After(time.Second)
+		for {
+			select {
+			case reloadedSource := <-renderer.sourceChan:
+				if reloadedSource == source {
+					return true
+				}
+			case <-timeout:
+				return false
+			}
+		}
+	}
+
+	t.Run("should reload the template when its source is written", func(t *testing.T) {
+		dir, _ := ioutil.TempDir("", "kube-template")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "nginx.tmpl")
+		_ = ioutil.WriteFile(path, []byte("v1"), 0644)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		renderer := newWatchedRenderer(t, ctx, path)
+		_ = ioutil.WriteFile(path, []byte("v2"), 0644)
+
+		assert.True(t, reloaded(renderer, "v2"))
+	})
+
+	t.Run("should reload the template when a configmap volume swaps its data", func(t *testing.T) {
+		dir, _ := ioutil.TempDir("", "kube-template")
+		defer os.RemoveAll(dir)
+		writeData := func(version, contents string) {
+			_ = os.Mkdir(filepath.Join(dir, version), 0755)
+			_ = ioutil.WriteFile(filepath.Join(dir, version, "nginx.tmpl"), []byte(contents), 0644)
+			_ = os.Symlink(version, filepath.Join(dir, "..data_tmp"))
+			_ = os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data"))
+		}
+		writeData("..v1", "v1")
+		_ = os.Symlink(filepath.Join("..data", "nginx.tmpl"), filepath.Join(dir, "nginx.tmpl"))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		renderer := newWatchedRenderer(t, ctx, filepath.Join(dir, "nginx.tmpl"))
+		writeData("..v2", "v2")
+
+		assert.True(t, reloaded(renderer, "v2"))
+	})
+
+	t.Run("should keep the previous template when the source is not a valid template", func(t *testing.T) {
+		dir, _ := ioutil.TempDir("", "kube-template")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "nginx.tmpl")
+		_ = ioutil.WriteFile(path, []byte("v1"), 0644)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		renderer := newWatchedRenderer(t, ctx, path)
+		_ = ioutil.WriteFile(path, []byte("{{ end"), 0644)
+		time.Sleep(100 * time.Millisecond)
+		select {
+		case source := <-renderer.sourceChan:
+			assert.NotEqual(t, "{{ end", source)
+		default:
+		}
+		_ = ioutil.WriteFile(path, []byte("v3"), 0644)
+
+		assert.True(t, reloaded(renderer, "v3"))
+	})
+}
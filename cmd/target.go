@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/afero"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultPerms os.FileMode = 0644
+
+// chowner is implemented by file systems which can change the ownership of files.
+// afero.Fs does not support it.
+type chowner interface {
+	Chown(name string, uid, gid int) error
+}
+
+// osFs is the OS file system along with support for changing ownership
+type osFs struct {
+	afero.Fs
+}
+
+func newOsFs() afero.Fs {
+	return osFs{afero.NewOsFs()}
+}
+
+func (osFs) Chown(name string, uid, gid int) error {
+	return os.Chown(name, uid, gid)
+}
+
+// target is the destination a template is rendered to.
+// "-" as path means STDOUT.
+type target struct {
+	fs   afero.Fs
+	path string
+	// perms of the file. 0 keeps the perms of an existing file or uses defaultPerms
+	perms os.FileMode
+	// uid and gid of the file. -1 leaves them unchanged
+	uid int
+	gid int
+	// fsync the file and its directory after every write
+	fsync bool
+	// keep the file when kube-template exits
+	keep bool
+	// number of previous versions to keep
+	backups int
+}
+
+func (t target) isStdout() bool {
+	return t.path == "-"
+}
+
+// write replaces the contents of the target atomically.
+// The contents are written to a temporary file in the same directory
+// which is then renamed over the target, so that readers of the target
+// never see a partially written file.
+func (t target) write(contents []byte) (err error) {
+	if t.isStdout() {
+		_, err = os.Stdout.Write(contents)
+		return err
+	}
+
+	dir := filepath.Dir(t.path)
+	tempFile, err := afero.TempFile(t.fs, dir, fmt.Sprintf(".%s.", filepath.Base(t.path)))
+	if err != nil {
+		return fmt.Errorf("error creating temporary file for %s: %w", t.path, err)
+	}
+	tempPath := tempFile.Name()
+	defer func() {
+		if err != nil {
+			_ = t.fs.Remove(tempPath)
+		}
+	}()
+
+	if _, err = tempFile.Write(contents); err != nil {
+		_ = tempFile.Close()
+		return fmt.Errorf("error writing temporary file %s: %w", tempPath, err)
+	}
+
+	if t.fsync {
+		if err = tempFile.Sync(); err != nil {
+			_ = tempFile.Close()
+			return fmt.Errorf("error syncing temporary file %s: %w", tempPath, err)
+		}
+	}
+
+	if err = tempFile.Close(); err != nil {
+		return fmt.Errorf("error closing temporary file %s: %w", tempPath, err)
+	}
+
+	if err = t.fs.Chmod(tempPath, t.fileMode()); err != nil {
+		return fmt.Errorf("error setting perms of %s: %w", tempPath, err)
+	}
+
+	if t.uid != -1 || t.gid != -1 {
+		if err = t.chown(tempPath); err != nil {
+			return err
+		}
+	}
+
+	// the previous contents are read before they are replaced,
+	// but backups are only rotated once the new contents are in place
+	var previous []byte
+	hasPrevious := false
+	if t.backups > 0 {
+		if previous, hasPrevious, err = t.previousContents(); err != nil {
+			return err
+		}
+	}
+
+	if err = t.fs.Rename(tempPath, t.path); err != nil {
+		return fmt.Errorf("error renaming %s to %s: %w", tempPath, t.path, err)
+	}
+
+	if hasPrevious {
+		if err = t.backup(previous); err != nil {
+			return err
+		}
+	}
+
+	if t.fsync {
+		return t.syncDir(dir)
+	}
+	return nil
+}
+
+// diff returns a unified diff between the current contents of the target and contents.
+// It is empty if they are the same. A target which does not exist is diffed as empty.
+func (t target) diff(contents []byte) (string, error) {
+	var current []byte
+	if !t.isStdout() {
+		var err error
+		current, err = afero.ReadFile(t.fs, t.path)
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("error reading %s: %w", t.path, err)
+		}
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(string(current)),
+		B:        splitLines(string(contents)),
+		FromFile: t.path,
+		ToFile:   t.path + " (rendered)",
+		Context:  3,
+	})
+}
+
+// splitLines keeps the line endings, which difflib expects.
+// A missing newline at the end is added so that the last line prints on its own.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+func (t target) fileMode() os.FileMode {
+	if t.perms != 0 {
+		return t.perms
+	}
+
+	if info, err := t.fs.Stat(t.path); err == nil {
+		return info.Mode().Perm()
+	}
+	return defaultPerms
+}
+
+// backupPath of the nth previous version. 0 is the latest one.
+func (t target) backupPath(n int) string {
+	if n == 0 {
+		return t.path + ".bak"
+	}
+	return fmt.Sprintf("%s.bak.%d", t.path, n)
+}
+
+// previousContents reads the current contents of the target, if it exists
+func (t target) previousContents() ([]byte, bool, error) {
+	contents, err := afero.ReadFile(t.fs, t.path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("error reading %s for backup: %w", t.path, err)
+	}
+	return contents, true, nil
+}
+
+// backup writes the previous contents of the target to the latest backup
+// after shifting older backups by one and dropping the oldest.
+func (t target) backup(previous []byte) error {
+	for n := t.backups - 2; n >= 0; n-- {
+		if exists, _ := afero.Exists(t.fs, t.backupPath(n)); !exists {
+			continue
+		}
+		if err := t.fs.Rename(t.backupPath(n), t.backupPath(n+1)); err != nil {
+			return fmt.Errorf("error rotating backup %s: %w", t.backupPath(n), err)
+		}
+	}
+
+	if err := afero.WriteFile(t.fs, t.backupPath(0), previous, t.fileMode()); err != nil {
+		return fmt.Errorf("error writing backup %s: %w", t.backupPath(0), err)
+	}
+	return nil
+}
+
+func (t target) chown(path string) error {
+	fs, ok := t.fs.(chowner)
+	if !ok {
+		return fmt.Errorf("error setting owner of %s: file system does not support it", path)
+	}
+
+	if err := fs.Chown(path, t.uid, t.gid); err != nil {
+		return fmt.Errorf("error setting owner of %s: %w", path, err)
+	}
+	return nil
+}
+
+// syncDir makes sure the rename is persisted
+func (t target) syncDir(dir string) error {
+	d, err := t.fs.Open(dir)
+	if err != nil {
+		return fmt.Errorf("error opening directory %s: %w", dir, err)
+	}
+	defer func() {
+		_ = d.Close()
+	}()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("error syncing directory %s: %w", dir, err)
+	}
+	return nil
+}
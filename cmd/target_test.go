@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+type chownRecorder struct {
+	afero.Fs
+	owners map[string][2]int
+}
+
+func (c *chownRecorder) Chown(name string, uid, gid int) error {
+	c.owners[name] = [2]int{uid, gid}
+	return nil
+}
+
+// failingRename fails renaming files to path
+type failingRename struct {
+	afero.Fs
+	path string
+}
+
+func (f *failingRename) Rename(oldname, newname string) error {
+	if newname == f.path {
+		return os.ErrPermission
+	}
+	return f.Fs.Rename(oldname, newname)
+}
+
+func TestTarget_Write(t *testing.T) {
+	t.Run("should replace the contents of the target without leaving temporary files", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = fs.MkdirAll("/etc/nginx", 0755)
+		_ = afero.WriteFile(fs, "/etc/nginx/upstreams.conf", []byte("old contents"), 0644)
+		target := target{fs: fs, path: "/etc/nginx/upstreams.conf", uid: -1, gid: -1, fsync: true}
+
+		err := target.write([]byte("new"))
+
+		assert.NoError(t, err)
+		contents, _ := afero.ReadFile(fs, "/etc/nginx/upstreams.conf")
+		assert.Equal(t, "new", string(contents))
+		files, _ := afero.ReadDir(fs, "/etc/nginx")
+		assert.Len(t, files, 1)
+	})
+
+	t.Run("should set perms of the target", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		target := target{fs: fs, path: "upstreams.conf", perms: 0600, uid: -1, gid: -1}
+
+		err := target.write([]byte("new"))
+
+		assert.NoError(t, err)
+		info, _ := fs.Stat("upstreams.conf")
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("should keep perms of an existing target if perms are not set", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "upstreams.conf", []byte("old"), 0640)
+		target := target{fs: fs, path: "upstreams.conf", uid: -1, gid: -1}
+
+		err := target.write([]byte("new"))
+
+		assert.NoError(t, err)
+		info, _ := fs.Stat("upstreams.conf")
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	})
+
+	t.Run("should set owner of the target", func(t *testing.T) {
+		fs := &chownRecorder{Fs: afero.NewMemMapFs(), owners: map[string][2]int{}}
+		target := target{fs: fs, path: "upstreams.conf", uid: 1000, gid: -1}
+
+		err := target.write([]byte("new"))
+
+		assert.NoError(t, err)
+		assert.Len(t, fs.owners, 1)
+		for _, owner := range fs.owners {
+			assert.Equal(t, [2]int{1000, -1}, owner)
+		}
+	})
+
+	t.Run("should return error and clean up if owner cannot be set", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		target := target{fs: fs, path: "upstreams.conf", uid: 1000, gid: 1000}
+
+		err := target.write([]byte("new"))
+
+		assert.Error(t, err)
+		files, _ := afero.ReadDir(fs, ".")
+		assert.Len(t, files, 0)
+	})
+}
+
+func TestTarget_Backup(t *testing.T) {
+	t.Run("should keep the previous version as .bak", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "upstreams.conf", []byte("v1"), 0644)
+		target := target{fs: fs, path: "upstreams.conf", uid: -1, gid: -1, backups: 1}
+
+		assert.NoError(t, target.write([]byte("v2")))
+		assert.NoError(t, target.write([]byte("v3")))
+
+		backup, _ := afero.ReadFile(fs, "upstreams.conf.bak")
+		assert.Equal(t, "v2", string(backup))
+		exists, _ := afero.Exists(fs, "upstreams.conf.bak.1")
+		assert.False(t, exists)
+	})
+
+	t.Run("should rotate the last n versions", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		target := target{fs: fs, path: "upstreams.conf", uid: -1, gid: -1, backups: 3}
+
+		for _, contents := range []string{"v1", "v2", "v3", "v4", "v5"} {
+			assert.NoError(t, target.write([]byte(contents)))
+		}
+
+		for path, expected := range map[string]string{
+			"upstreams.conf":       "v5",
+			"upstreams.conf.bak":   "v4",
+			"upstreams.conf.bak.1": "v3",
+			"upstreams.conf.bak.2": "v2",
+		} {
+			contents, _ := afero.ReadFile(fs, path)
+			assert.Equal(t, expected, string(contents), path)
+		}
+		exists, _ := afero.Exists(fs, "upstreams.conf.bak.3")
+		assert.False(t, exists)
+	})
+
+	t.Run("should not rotate backups if the target cannot be replaced", func(t *testing.T) {
+		memFs := afero.NewMemMapFs()
+		_ = afero.WriteFile(memFs, "upstreams.conf", []byte("v2"), 0644)
+		_ = afero.WriteFile(memFs, "upstreams.conf.bak", []byte("v1"), 0644)
+		target := target{fs: &failingRename{Fs: memFs, path: "upstreams.conf"}, path: "upstreams.conf", uid: -1, gid: -1, backups: 1}
+
+		assert.Error(t, target.write([]byte("v3")))
+
+		for path, expected := range map[string]string{
+			"upstreams.conf":     "v2",
+			"upstreams.conf.bak": "v1",
+		} {
+			contents, _ := afero.ReadFile(memFs, path)
+			assert.Equal(t, expected, string(contents), path)
+		}
+	})
+}
@@ -3,59 +3,456 @@ package cmd
 import (
 	"fmt"
 	"github.com/spf13/afero"
+	"github.com/thecasualcoder/kube-template/pkg/config"
+	"github.com/thecasualcoder/kube-template/pkg/kubernetes"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type templateArg struct {
-	source string
-	target afero.File
+	// sourcePath is the file source was read from.
+	// It is empty for inline templates and "-" for STDIN
+	sourcePath string
+	source     string
+	target     target
+	command    *postRenderCommand
+	wait       waitWindow
 }
 
-func newTemplateArg(fs afero.Fs, templateFlagValue string) (templateArg, error) {
-	templateValue := strings.Split(templateFlagValue, ":")
-	if len(templateValue) != 2 {
-		return templateArg{}, fmt.Errorf("template flag format is wrong")
+// hasSourceFile is true if the source can be read again from disk
+func (t templateArg) hasSourceFile() bool {
+	return t.sourcePath != "" && t.sourcePath != stdinSource
+}
+
+// waitWindow is the quiescence window of a template.
+// It is written once there have been no changes for min,
+// but never later than max after the first pending change.
+type waitWindow struct {
+	min time.Duration
+	max time.Duration
+}
+
+// runConfig holds everything run needs to render templates
+type runConfig struct {
+	fs             afero.Fs
+	templateArgs   []templateArg
+	watchTemplates bool
+	execCommand    *execCommand
+	clientConfig   kubernetes.Config
+	clusterConfigs map[string]kubernetes.Config
+	once           bool
+	onceTimeout    time.Duration
+	dryRun         bool
+}
+
+// parseTemplateFlag parses either the legacy "source:destination" form
+// or the key value form "source=a.tmpl,destination=/etc/a.conf,perms=0640".
+// Keys are the same as the keys of templates in the config file.
+// Values containing commas can be quoted with double quotes, like command="a, b".
+func parseTemplateFlag(templateFlagValue string) (*config.TemplateConfig, error) {
+	if !isKeyValueTemplateFlag(templateFlagValue) {
+		templateValue := strings.Split(templateFlagValue, ":")
+		if len(templateValue) != 2 {
+			return nil, fmt.Errorf("template flag format is wrong. Should be \"source:destination\" or \"source=...,destination=...\"")
+		}
+
+		return &config.TemplateConfig{
+			Source:      templateValue[0],
+			Destination: templateValue[1],
+		}, nil
+	}
+
+	fields, err := splitTemplateFlag(templateFlagValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template flag \"%s\": %w", templateFlagValue, err)
+	}
+
+	template := &config.TemplateConfig{}
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid template flag \"%s\": \"%s\" should be of the format key=value", templateFlagValue, field)
+		}
+
+		key, value := strings.TrimSpace(parts[0]), parts[1]
+		if seen[key] {
+			return nil, fmt.Errorf("invalid template flag \"%s\": key \"%s\" is repeated", templateFlagValue, key)
+		}
+		seen[key] = true
+
+		if err := setTemplateFlagKey(template, key, value); err != nil {
+			return nil, fmt.Errorf("invalid template flag \"%s\": %w", templateFlagValue, err)
+		}
+	}
+
+	if template.Source == "" && template.Contents == "" {
+		return nil, fmt.Errorf("invalid template flag \"%s\": key \"source\" or \"contents\" is required", templateFlagValue)
+	}
+	if template.Source != "" && template.Contents != "" {
+		return nil, fmt.Errorf("invalid template flag \"%s\": keys \"source\" and \"contents\" cannot be used together", templateFlagValue)
+	}
+	if template.Destination == "" {
+		return nil, fmt.Errorf("invalid template flag \"%s\": key \"destination\" is required", templateFlagValue)
 	}
-	sourceFilePath := templateValue[0]
-	targetFilePath := templateValue[1]
+	return template, nil
+}
+
+var templateFlagKeys = []string{
+	"source", "contents", "destination", "perms", "uid", "gid", "fsync", "overwrite", "keep_on_exit", "backups",
+	"command", "command_timeout", "on_command_failure", "command_retries", "wait",
+}
+
+// keyValueField matches a key=value field after the first one
+var keyValueField = regexp.MustCompile(`,\s*[A-Za-z_]+=`)
+
+// isKeyValueTemplateFlag is true if the value starts with one of templateFlagKeys followed by "=",
+// or if it has a "=" before the first ":" and more than one key=value field,
+// so that a misspelt first key is reported instead of being read as a path.
+// Anything else is treated as the legacy form so that existing paths containing "=" keep working.
+func isKeyValueTemplateFlag(value string) bool {
+	for _, key := range templateFlagKeys {
+		if strings.HasPrefix(value, key+"=") {
+			return true
+		}
+	}
+
+	first := strings.SplitN(value, ":", 2)[0]
+	return strings.Contains(first, "=") && keyValueField.MatchString(value)
+}
+
+// setTemplateFlagKey validates value and sets it on the template
+func setTemplateFlagKey(template *config.TemplateConfig, key, value string) error {
+	var err error
+	switch key {
+	case "source":
+		template.Source = value
+	case "contents":
+		template.Contents = value
+	case "destination":
+		template.Destination = value
+	case "perms":
+		_, err = parsePerms(value)
+		template.Perms = value
+	case "uid":
+		template.UID, err = parseIntPointer(value)
+	case "gid":
+		template.GID, err = parseIntPointer(value)
+	case "fsync":
+		template.Fsync, err = parseBool(value)
+	case "overwrite":
+		template.Overwrite, err = parseBool(value)
+	case "keep_on_exit":
+		template.KeepOnExit, err = parseBool(value)
+	case "backups":
+		var backups *int
+		if backups, err = parseIntPointer(value); err == nil {
+			if *backups < 0 {
+				err = fmt.Errorf("cannot be negative")
+			}
+			template.Backups = *backups
+		}
+	case "command":
+		template.Command = value
+	case "command_timeout":
+		_, err = parseDuration(value, 0)
+		template.CommandTimeout = value
+	case "on_command_failure":
+		_, err = parseCommandFailurePolicy(value)
+		template.OnCommandFailure = value
+	case "command_retries":
+		template.CommandRetries, err = parseIntPointer(value)
+	case "wait":
+		template.Wait, err = config.ParseWait(value)
+	default:
+		return fmt.Errorf("unknown key \"%s\". Should be one of %s", key, strings.Join(templateFlagKeys, ", "))
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid value \"%s\" for key \"%s\": %w", value, key, err)
+	}
+	return nil
+}
+
+func parseIntPointer(value string) (*int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("should be a number")
+	}
+	return &i, nil
+}
 
-	sourceTemplateContents, err := getSourceContents(fs, sourceFilePath)
+func parseBool(value string) (bool, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("should be true or false")
+	}
+	return b, nil
+}
+
+// splitTemplateFlag splits the key value form on commas which are not inside double quotes.
+// The quotes are removed and \" and \\ inside them are unescaped.
+func splitTemplateFlag(value string) ([]string, error) {
+	var fields []string
+	var current strings.Builder
+
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch r {
+		case ',':
+			fields = append(fields, current.String())
+			current.Reset()
+		case '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					i++
+				}
+				current.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	return append(fields, current.String()), nil
+}
+
+// parseTemplateStringFlag parses "contents:destination".
+// The destination is after the last colon, so that the contents can contain colons.
+func parseTemplateStringFlag(templateStringFlagValue string) (*config.TemplateConfig, error) {
+	i := strings.LastIndex(templateStringFlagValue, ":")
+	if i <= 0 || i == len(templateStringFlagValue)-1 {
+		return nil, fmt.Errorf("template string flag format is wrong. Should be \"{{ template }}:destination\"")
+	}
+
+	return &config.TemplateConfig{
+		Contents:    templateStringFlagValue[:i],
+		Destination: templateStringFlagValue[i+1:],
+	}, nil
+}
+
+// parseClusterFlag parses "name=context"
+func parseClusterFlag(clusterFlagValue string) (string, string, error) {
+	parts := strings.SplitN(clusterFlagValue, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.HasPrefix(parts[0], "@") {
+		return "", "", fmt.Errorf("cluster flag format is wrong. Should be \"name=context\"")
+	}
+	return parts[0], parts[1], nil
+}
+
+// newClusterConfigs returns the configs of the clusters with their kubeconfig contexts, by name.
+// They share every setting of clientConfig except the context.
+// Contexts come from a kubeconfig, so they never use the in-cluster config.
+func newClusterConfigs(clientConfig kubernetes.Config, clusters map[string]string) map[string]kubernetes.Config {
+	clusterConfigs := map[string]kubernetes.Config{}
+	for name, clusterContext := range clusters {
+		clusterConfig := clientConfig
+		clusterConfig.Context = clusterContext
+		clusterConfig.InCluster = false
+		clusterConfigs[name] = clusterConfig
+	}
+	return clusterConfigs
+}
+
+// defaultClusterName is the name of the cluster using context, the context of the default cluster.
+// The default cluster is named after its context if no cluster uses it.
+func defaultClusterName(context string, clusterConfigs map[string]kubernetes.Config) (string, error) {
+	names := []string{}
+	for name, clusterConfig := range clusterConfigs {
+		if clusterConfig.Context == context {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	switch len(names) {
+	case 0:
+		return context, nil
+	case 1:
+		return names[0], nil
+	default:
+		return "", fmt.Errorf("clusters %s use the same context %s", strings.Join(names, " and "), context)
+	}
+}
+
+func newTemplateArgs(fs afero.Fs, templates []*config.TemplateConfig, defaultWait waitWindow) ([]templateArg, error) {
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("at least one template is required")
+	}
+
+	stdinSources := 0
+	for _, template := range templates {
+		if template.Source == stdinSource {
+			stdinSources++
+		}
+	}
+	if stdinSources > 1 {
+		return nil, fmt.Errorf("only one template can be read from STDIN")
+	}
+
+	templateArgs := make([]templateArg, 0, len(templates))
+	for _, template := range templates {
+		arg, err := newTemplateArg(fs, template, defaultWait)
+		if err != nil {
+			return nil, err
+		}
+		templateArgs = append(templateArgs, arg)
+	}
+
+	return templateArgs, nil
+}
+
+// removeTargets removes every target which is not kept on exit
+func removeTargets(templateArgs []templateArg) {
+	for _, arg := range templateArgs {
+		if !arg.target.isStdout() && !arg.target.keep {
+			_ = arg.target.fs.Remove(arg.target.path)
+		}
+	}
+}
+
+func newTemplateArg(fs afero.Fs, template *config.TemplateConfig, defaultWait waitWindow) (templateArg, error) {
+	wait, err := parseWait(template.Wait, defaultWait)
+	if err != nil {
+		return templateArg{}, fmt.Errorf("invalid wait for template %s: %w", templateName(template), err)
+	}
+
+	perms, err := parsePerms(template.Perms)
+	if err != nil {
+		return templateArg{}, fmt.Errorf("invalid perms for template %s: %w", templateName(template), err)
+	}
+
+	command, err := newPostRenderCommand(template)
 	if err != nil {
 		return templateArg{}, err
 	}
 
-	target, err := getTargetFile(fs, targetFilePath)
+	sourceTemplateContents := template.Contents
+	if sourceTemplateContents == "" {
+		if sourceTemplateContents, err = getSourceContents(fs, template.Source); err != nil {
+			return templateArg{}, err
+		}
+	}
+
+	target, err := newTarget(fs, template, perms)
 	if err != nil {
 		return templateArg{}, err
 	}
 
 	return templateArg{
-		source: sourceTemplateContents,
-		target: target,
+		sourcePath: template.Source,
+		source:     sourceTemplateContents,
+		target:     target,
+		command:    command,
+		wait:       wait,
 	}, nil
 }
 
-func getTargetFile(fs afero.Fs, targetFilePath string) (afero.File, error) {
-	if targetFilePath == "-" {
-		return os.Stdout, nil
+// newPostRenderCommand returns nil if the template does not have a command
+func newPostRenderCommand(template *config.TemplateConfig) (*postRenderCommand, error) {
+	if template.Command == "" {
+		return nil, nil
 	}
 
-	if exists, err := afero.Exists(fs, targetFilePath); err != nil {
-		return nil, err
-	} else if exists {
-		return nil, fmt.Errorf("target file  \"%s\" already exists", targetFilePath)
+	timeout, err := parseDuration(template.CommandTimeout, defaultCommandTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command_timeout for template %s: %w", templateName(template), err)
 	}
 
-	targetFile, err := fs.Create(targetFilePath)
+	onFailure, err := parseCommandFailurePolicy(template.OnCommandFailure)
 	if err != nil {
-		return nil, fmt.Errorf("error opening file handle to target: %w", err)
+		return nil, fmt.Errorf("invalid on_command_failure for template %s: %w", templateName(template), err)
+	}
+
+	retries := defaultCommandRetries
+	if template.CommandRetries != nil {
+		if *template.CommandRetries < 0 {
+			return nil, fmt.Errorf("invalid command_retries for template %s: cannot be negative", templateName(template))
+		}
+		retries = *template.CommandRetries
+	}
+
+	return &postRenderCommand{
+		command:       template.Command,
+		timeout:       timeout,
+		onFailure:     onFailure,
+		retries:       retries,
+		retryInterval: commandRetryInterval,
+	}, nil
+}
+
+func newTarget(fs afero.Fs, template *config.TemplateConfig, perms os.FileMode) (target, error) {
+	t := target{
+		fs:      fs,
+		path:    template.Destination,
+		perms:   perms,
+		uid:     -1,
+		gid:     -1,
+		fsync:   template.Fsync,
+		keep:    template.KeepOnExit,
+		backups: template.Backups,
+	}
+	if template.UID != nil {
+		t.uid = *template.UID
 	}
+	if template.GID != nil {
+		t.gid = *template.GID
+	}
+
+	if t.isStdout() {
+		return t, nil
+	}
+
+	if exists, err := afero.Exists(fs, t.path); err != nil {
+		return target{}, err
+	} else if exists && !template.Overwrite {
+		return target{}, fmt.Errorf("target file  \"%s\" already exists. Set overwrite to replace it", t.path)
+	}
+
+	if exists, err := afero.DirExists(fs, filepath.Dir(t.path)); err != nil {
+		return target{}, err
+	} else if !exists {
+		return target{}, fmt.Errorf("directory of target file \"%s\" does not exist", t.path)
+	}
+
+	return t, nil
+}
 
-	return targetFile, nil
+// templateName identifies a template in errors
+func templateName(template *config.TemplateConfig) string {
+	if template.Source != "" {
+		return template.Source
+	}
+	return fmt.Sprintf("inline template of %s", template.Destination)
 }
 
+// stdinSource as the source of a template means it is read from STDIN
+const stdinSource = "-"
+
+// stdin is read by templates whose source is stdinSource
+var stdin io.Reader = os.Stdin
+
 func getSourceContents(fs afero.Fs, sourceFilePath string) (string, error) {
+	if sourceFilePath == stdinSource {
+		contents, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("error reading source template from STDIN: %w", err)
+		}
+		return string(contents), nil
+	}
+
 	if exists, err := afero.Exists(fs, sourceFilePath); err != nil {
 		return "", err
 	} else if !exists {
@@ -70,18 +467,99 @@ func getSourceContents(fs afero.Fs, sourceFilePath string) (string, error) {
 	return string(sourceTemplateContentsBytes), nil
 }
 
+// parseDuration parses a go duration string. Empty value means defaultValue.
+func parseDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("\"%s\" is not a valid duration", value)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("\"%s\" cannot be negative", value)
+	}
+	return duration, nil
+}
+
+// parseWait converts a wait from the config. Nil config means defaultWait.
+// Max defaults to 4 times min.
+func parseWait(waitConfig *config.WaitConfig, defaultWait waitWindow) (waitWindow, error) {
+	if waitConfig == nil {
+		return defaultWait, nil
+	}
+
+	min, err := parseDuration(waitConfig.Min, defaultWait.min)
+	if err != nil {
+		return waitWindow{}, fmt.Errorf("invalid min: %w", err)
+	}
+
+	max, err := parseDuration(waitConfig.Max, 4*min)
+	if err != nil {
+		return waitWindow{}, fmt.Errorf("invalid max: %w", err)
+	}
+
+	if max < min {
+		return waitWindow{}, fmt.Errorf("max %s cannot be less than min %s", max, min)
+	}
+	return waitWindow{min: min, max: max}, nil
+}
+
+// parsePerms parses an octal file mode like "0644". Empty value means 0.
+func parsePerms(value string) (os.FileMode, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	perms, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || perms > 0777 {
+		return 0, fmt.Errorf("\"%s\" is not a valid octal file mode", value)
+	}
+	return os.FileMode(perms), nil
+}
+
 type execCommand struct {
-	command string
-	args    []string
+	command      string
+	args         []string
+	killTimeout  time.Duration
+	killSignal   os.Signal
+	reloadSignal os.Signal
 }
 
-func newExecCommand(execFlag string) (execCommand, error) {
-	if execFlag == "" {
+func newExecCommand(execConfig *config.ExecConfig) (execCommand, error) {
+	if execConfig.Command == "" {
 		return execCommand{}, fmt.Errorf("execCommand flag cannot be empty")
 	}
-	split := strings.Split(execFlag, " ")
+
+	killTimeout, err := parseDuration(execConfig.KillTimeout, defaultKillTimeout)
+	if err != nil {
+		return execCommand{}, fmt.Errorf("invalid kill_timeout for exec: %w", err)
+	}
+
+	killSignal, err := parseSignal(execConfig.KillSignal, os.Interrupt)
+	if err != nil {
+		return execCommand{}, fmt.Errorf("invalid kill_signal for exec: %w", err)
+	}
+
+	reloadSignal, err := parseSignal(execConfig.ReloadSignal, nil)
+	if err != nil {
+		return execCommand{}, fmt.Errorf("invalid reload_signal for exec: %w", err)
+	}
+
+	split, err := splitCommand(execConfig.Command)
+	if err != nil {
+		return execCommand{}, fmt.Errorf("invalid exec command: %w", err)
+	}
+	if len(split) == 0 {
+		return execCommand{}, fmt.Errorf("execCommand flag cannot be empty")
+	}
+
 	return execCommand{
-		command: split[0],
-		args:    split[1:],
+		command:      split[0],
+		args:         split[1:],
+		killTimeout:  killTimeout,
+		killSignal:   killSignal,
+		reloadSignal: reloadSignal,
 	}, nil
 }
@@ -0,0 +1,355 @@
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/thecasualcoder/kube-template/pkg/config"
+	"github.com/thecasualcoder/kube-template/pkg/kubernetes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTemplateFlag(t *testing.T) {
+	t.Run("should parse source and destination", func(t *testing.T) {
+		template, err := parseTemplateFlag("a.tmpl:a.conf")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, &config.TemplateConfig{Source: "a.tmpl", Destination: "a.conf"}, template)
+		}
+	})
+
+	t.Run("should return error if format is wrong", func(t *testing.T) {
+		_, err := parseTemplateFlag("a.tmpl")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should parse key value form with options", func(t *testing.T) {
+		template, err := parseTemplateFlag(`source=c:/a.tmpl,destination=/etc/a:b.conf,perms=0640,uid=101,overwrite=true,backups=2,wait=1s:4s,command="nginx -s reload, really"`)
+
+		uid := 101
+		if assert.NoError(t, err) {
+			assert.Equal(t, &config.TemplateConfig{
+				Source:      "c:/a.tmpl",
+				Destination: "/etc/a:b.conf",
+				Perms:       "0640",
+				UID:         &uid,
+				Overwrite:   true,
+				Backups:     2,
+				Wait:        &config.WaitConfig{Min: "1s", Max: "4s"},
+				Command:     "nginx -s reload, really",
+			}, template)
+		}
+	})
+
+	t.Run("should unescape quotes in quoted values", func(t *testing.T) {
+		template, err := parseTemplateFlag(`source=a.tmpl,destination=a.conf,command="echo \"done\""`)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, `echo "done"`, template.Command)
+		}
+	})
+
+	t.Run("should treat paths with = as the legacy form", func(t *testing.T) {
+		template, err := parseTemplateFlag("a=b.tmpl:a.conf")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, &config.TemplateConfig{Source: "a=b.tmpl", Destination: "a.conf"}, template)
+		}
+	})
+
+	t.Run("should return error naming the offending key", func(t *testing.T) {
+		cases := map[string]string{
+			"source=a.tmpl,destination=a.conf,mode=0640":         `unknown key "mode"`,
+			"sourc=a.tmpl,destination=a.conf":                    `unknown key "sourc"`,
+			"source=a.tmpl,destination=a.conf,perms=999":         `invalid value "999" for key "perms"`,
+			"source=a.tmpl,destination=a.conf,uid=root":          `invalid value "root" for key "uid": should be a number`,
+			"source=a.tmpl,destination=a.conf,fsync=yes":         `invalid value "yes" for key "fsync": should be true or false`,
+			"source=a.tmpl,destination=a.conf,backups=-1":        `invalid value "-1" for key "backups": cannot be negative`,
+			"source=a.tmpl,destination=a.conf,wait=1s:2s:3s":     `invalid value "1s:2s:3s" for key "wait"`,
+			"source=a.tmpl,destination=a.conf,command_timeout=x": `invalid value "x" for key "command_timeout"`,
+			"source=a.tmpl,source=b.tmpl,destination=a.conf":     `key "source" is repeated`,
+			"source=a.tmpl":                                  `key "destination" is required`,
+			"source=a.tmpl,destination":                      `"destination" should be of the format key=value`,
+			`source=a.tmpl,destination=a.conf,command="echo`: "unterminated double quote",
+		}
+
+		for value, expected := range cases {
+			_, err := parseTemplateFlag(value)
+
+			if assert.Error(t, err, value) {
+				assert.Contains(t, err.Error(), expected)
+			}
+		}
+	})
+}
+
+func TestParseTemplateStringFlag(t *testing.T) {
+	t.Run("should split contents and destination on the last colon", func(t *testing.T) {
+		template, err := parseTemplateStringFlag(`{{ range .Subsets }}{{ .IP }}:{{ .Port }}{{ end }}:-`)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, &config.TemplateConfig{Contents: `{{ range .Subsets }}{{ .IP }}:{{ .Port }}{{ end }}`, Destination: "-"}, template)
+		}
+	})
+
+	t.Run("should return error if contents or destination is missing", func(t *testing.T) {
+		for _, value := range []string{"{{ . }}", ":-", "{{ . }}:"} {
+			_, err := parseTemplateStringFlag(value)
+
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func TestParseClusterFlag(t *testing.T) {
+	t.Run("should split name and context on the first equals sign", func(t *testing.T) {
+		name, context, err := parseClusterFlag("eu-west=arn:aws:eks:eu-west-1:123:cluster/prod=1")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "eu-west", name)
+			assert.Equal(t, "arn:aws:eks:eu-west-1:123:cluster/prod=1", context)
+		}
+	})
+
+	t.Run("should return error if name or context is missing", func(t *testing.T) {
+		for _, value := range []string{"eu-west", "=prod", "eu-west=", "@eu-west=prod"} {
+			_, _, err := parseClusterFlag(value)
+
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func TestNewClusterConfigs(t *testing.T) {
+	t.Run("should share every setting except the context", func(t *testing.T) {
+		clientConfig := kubernetes.Config{Kubeconfig: "/etc/kubeconfig", Context: "prod", Namespace: "web", As: "deployer"}
+
+		clusterConfigs := newClusterConfigs(clientConfig, map[string]string{"eu-west": "eu"})
+
+		assert.Equal(t, map[string]kubernetes.Config{
+			"eu-west": {Kubeconfig: "/etc/kubeconfig", Context: "eu", Namespace: "web", As: "deployer"},
+		}, clusterConfigs)
+	})
+
+	t.Run("should read other clusters from the kubeconfig when the default one is in-cluster", func(t *testing.T) {
+		clientConfig := kubernetes.Config{InCluster: true, Namespace: "web"}
+
+		clusterConfigs := newClusterConfigs(clientConfig, map[string]string{"eu-west": "eu"})
+
+		assert.Equal(t, map[string]kubernetes.Config{
+			"eu-west": {Context: "eu", Namespace: "web"},
+		}, clusterConfigs)
+	})
+}
+
+func TestDefaultClusterName(t *testing.T) {
+	t.Run("should name the default cluster after its context", func(t *testing.T) {
+		name, err := defaultClusterName("prod", map[string]kubernetes.Config{"eu-west": {Context: "eu"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "prod", name)
+	})
+
+	t.Run("should use the name of the cluster with the same context", func(t *testing.T) {
+		name, err := defaultClusterName("prod", map[string]kubernetes.Config{"eu-west": {Context: "eu"}, "us-east": {Context: "prod"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "us-east", name)
+	})
+
+	t.Run("should return error if more than one cluster has the same context", func(t *testing.T) {
+		_, err := defaultClusterName("prod", map[string]kubernetes.Config{"primary": {Context: "prod"}, "us-east": {Context: "prod"}})
+
+		assert.EqualError(t, err, "clusters primary and us-east use the same context prod")
+	})
+}
+
+func TestNewTemplateArgs(t *testing.T) {
+	defaultWait := waitWindow{min: time.Second, max: 4 * time.Second}
+
+	t.Run("should create a template arg for every template", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "a.tmpl", []byte("a"), 0644)
+		_ = afero.WriteFile(fs, "b.tmpl", []byte("b"), 0644)
+		templates := []*config.TemplateConfig{
+			{Source: "a.tmpl", Destination: "a.conf", Command: "reload a"},
+			{Source: "b.tmpl", Destination: "b.conf", Wait: &config.WaitConfig{Min: "5s"}},
+		}
+
+		templateArgs, err := newTemplateArgs(fs, templates, defaultWait)
+
+		if assert.NoError(t, err) && assert.Len(t, templateArgs, 2) {
+			assert.Equal(t, "a", templateArgs[0].source)
+			assert.Equal(t, "a.conf", templateArgs[0].target.path)
+			assert.Equal(t, &postRenderCommand{
+				command:       "reload a",
+				timeout:       defaultCommandTimeout,
+				onFailure:     ignoreFailure,
+				retries:       defaultCommandRetries,
+				retryInterval: commandRetryInterval,
+			}, templateArgs[0].command)
+			assert.Equal(t, defaultWait, templateArgs[0].wait)
+			assert.Equal(t, "b", templateArgs[1].source)
+			assert.Equal(t, "b.conf", templateArgs[1].target.path)
+			assert.Equal(t, waitWindow{min: 5 * time.Second, max: 20 * time.Second}, templateArgs[1].wait)
+			assert.Nil(t, templateArgs[1].command)
+		}
+	})
+
+	t.Run("should set perms and owner of the target", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "a.tmpl", []byte("a"), 0644)
+		uid := 1000
+		templates := []*config.TemplateConfig{{Source: "a.tmpl", Destination: "a.conf", Perms: "0600", UID: &uid, Fsync: true}}
+
+		templateArgs, err := newTemplateArgs(fs, templates, defaultWait)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, target{fs: fs, path: "a.conf", perms: 0600, uid: 1000, gid: -1, fsync: true}, templateArgs[0].target)
+		}
+	})
+
+	t.Run("should not create targets before they are rendered", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "a.tmpl", []byte("a"), 0644)
+		templates := []*config.TemplateConfig{{Source: "a.tmpl", Destination: "a.conf"}}
+
+		_, err := newTemplateArgs(fs, templates, defaultWait)
+
+		assert.NoError(t, err)
+		exists, _ := afero.Exists(fs, "a.conf")
+		assert.False(t, exists)
+	})
+
+	t.Run("should use inline contents as the source", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		templates := []*config.TemplateConfig{{Contents: "{{ . }}", Destination: "-"}}
+
+		templateArgs, err := newTemplateArgs(fs, templates, defaultWait)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "{{ . }}", templateArgs[0].source)
+			assert.False(t, templateArgs[0].hasSourceFile())
+		}
+	})
+
+	t.Run("should read the source from STDIN", func(t *testing.T) {
+		defer func(original io.Reader) { stdin = original }(stdin)
+		stdin = strings.NewReader("from stdin")
+		fs := afero.NewMemMapFs()
+		templates := []*config.TemplateConfig{{Source: "-", Destination: "a.conf"}}
+
+		templateArgs, err := newTemplateArgs(fs, templates, defaultWait)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "from stdin", templateArgs[0].source)
+			assert.False(t, templateArgs[0].hasSourceFile())
+		}
+	})
+
+	t.Run("should return error if more than one source is STDIN", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		templates := []*config.TemplateConfig{{Source: "-", Destination: "a.conf"}, {Source: "-", Destination: "b.conf"}}
+
+		_, err := newTemplateArgs(fs, templates, defaultWait)
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "only one template can be read from STDIN")
+		}
+	})
+
+	t.Run("should return error if source does not exist", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		templates := []*config.TemplateConfig{{Source: "missing.tmpl", Destination: "a.conf"}}
+
+		_, err := newTemplateArgs(fs, templates, defaultWait)
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "source template \"missing.tmpl\" does not exist")
+		}
+	})
+
+	t.Run("should return error if target already exists", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "a.tmpl", []byte("a"), 0644)
+		_ = afero.WriteFile(fs, "a.conf", []byte("a"), 0644)
+		templates := []*config.TemplateConfig{{Source: "a.tmpl", Destination: "a.conf"}}
+
+		_, err := newTemplateArgs(fs, templates, defaultWait)
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "already exists")
+		}
+	})
+
+	t.Run("should allow existing target if overwrite is set", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "a.tmpl", []byte("a"), 0644)
+		_ = afero.WriteFile(fs, "a.conf", []byte("a"), 0644)
+		templates := []*config.TemplateConfig{{Source: "a.tmpl", Destination: "a.conf", Overwrite: true, KeepOnExit: true, Backups: 2}}
+
+		templateArgs, err := newTemplateArgs(fs, templates, defaultWait)
+
+		if assert.NoError(t, err) {
+			assert.True(t, templateArgs[0].target.keep)
+			assert.Equal(t, 2, templateArgs[0].target.backups)
+		}
+	})
+
+	t.Run("should return error if wait is not a duration", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "a.tmpl", []byte("a"), 0644)
+		templates := []*config.TemplateConfig{{Source: "a.tmpl", Destination: "a.conf", Wait: &config.WaitConfig{Min: "soon"}}}
+
+		_, err := newTemplateArgs(fs, templates, defaultWait)
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "invalid wait for template a.tmpl")
+		}
+	})
+
+	t.Run("should return error if command failure policy is unknown", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "a.tmpl", []byte("a"), 0644)
+		templates := []*config.TemplateConfig{{Source: "a.tmpl", Destination: "a.conf", Command: "true", OnCommandFailure: "panic"}}
+
+		_, err := newTemplateArgs(fs, templates, defaultWait)
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "invalid on_command_failure for template a.tmpl")
+		}
+	})
+
+	t.Run("should return error if no template is given", func(t *testing.T) {
+		_, err := newTemplateArgs(afero.NewMemMapFs(), nil, defaultWait)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestParseWait(t *testing.T) {
+	defaultWait := waitWindow{min: 2 * time.Second, max: 8 * time.Second}
+
+	t.Run("should return default wait if config is nil", func(t *testing.T) {
+		wait, err := parseWait(nil, defaultWait)
+
+		assert.NoError(t, err)
+		assert.Equal(t, defaultWait, wait)
+	})
+
+	t.Run("should parse min and max", func(t *testing.T) {
+		wait, err := parseWait(&config.WaitConfig{Min: "1s", Max: "10s"}, defaultWait)
+
+		assert.NoError(t, err)
+		assert.Equal(t, waitWindow{min: time.Second, max: 10 * time.Second}, wait)
+	})
+
+	t.Run("should return error if max is less than min", func(t *testing.T) {
+		_, err := parseWait(&config.WaitConfig{Min: "10s", Max: "1s"}, defaultWait)
+
+		assert.Error(t, err)
+	})
+}
@@ -1,14 +1,17 @@
 module github.com/thecasualcoder/kube-template
 
-go 1.13
+go 1.16
 
 require (
-	github.com/golang/mock v1.2.0
-	github.com/mitchellh/go-homedir v1.1.0
+	github.com/fsnotify/fsnotify v1.4.9
+	github.com/golang/mock v1.5.0
+	github.com/hashicorp/hcl v1.0.0
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/spf13/afero v1.2.2
 	github.com/spf13/cobra v0.0.5
-	github.com/stretchr/testify v1.4.0
-	k8s.io/api v0.17.0
-	k8s.io/apimachinery v0.17.0
-	k8s.io/client-go v0.17.0
+	github.com/stretchr/testify v1.8.0
+	gopkg.in/yaml.v2 v2.4.0
+	k8s.io/api v0.23.17
+	k8s.io/apimachinery v0.23.17
+	k8s.io/client-go v0.23.17
 )
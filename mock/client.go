@@ -6,7 +6,12 @@ package mock
 
 import (
 	gomock "github.com/golang/mock/gomock"
-	v1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/apps/v1"
+	v10 "k8s.io/api/coordination/v1"
+	v11 "k8s.io/api/core/v1"
+	v12 "k8s.io/api/discovery/v1"
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	watch "k8s.io/apimachinery/pkg/watch"
 	reflect "reflect"
 )
@@ -34,11 +39,39 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// Namespace mocks base method
+func (m *MockClient) Namespace() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Namespace")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Namespace indicates an expected call of Namespace
+func (mr *MockClientMockRecorder) Namespace() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Namespace", reflect.TypeOf((*MockClient)(nil).Namespace))
+}
+
+// Context mocks base method
+func (m *MockClient) Context() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Context indicates an expected call of Context
+func (mr *MockClientMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockClient)(nil).Context))
+}
+
 // GetEndpoints mocks base method
-func (m *MockClient) GetEndpoints(namespace, name string) (*v1.Endpoints, error) {
+func (m *MockClient) GetEndpoints(namespace, name string) (*v11.Endpoints, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetEndpoints", namespace, name)
-	ret0, _ := ret[0].(*v1.Endpoints)
+	ret0, _ := ret[0].(*v11.Endpoints)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -65,10 +98,10 @@ func (mr *MockClientMockRecorder) WatchEndpoints(namespace, name interface{}) *g
 }
 
 // GetPodsWithLabels mocks base method
-func (m *MockClient) GetPodsWithLabels(namespace, labelSelectors string) (*v1.PodList, error) {
+func (m *MockClient) GetPodsWithLabels(namespace, labelSelectors string) (*v11.PodList, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPodsWithLabels", namespace, labelSelectors)
-	ret0, _ := ret[0].(*v1.PodList)
+	ret0, _ := ret[0].(*v11.PodList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -93,3 +126,378 @@ func (mr *MockClientMockRecorder) WatchPodsWithLabels(namespace, labelSelectors
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchPodsWithLabels", reflect.TypeOf((*MockClient)(nil).WatchPodsWithLabels), namespace, labelSelectors)
 }
+
+// GetService mocks base method
+func (m *MockClient) GetService(namespace, name string) (*v11.Service, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetService", namespace, name)
+	ret0, _ := ret[0].(*v11.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetService indicates an expected call of GetService
+func (mr *MockClientMockRecorder) GetService(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetService", reflect.TypeOf((*MockClient)(nil).GetService), namespace, name)
+}
+
+// WatchService mocks base method
+func (m *MockClient) WatchService(namespace, name string) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchService", namespace, name)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchService indicates an expected call of WatchService
+func (mr *MockClientMockRecorder) WatchService(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchService", reflect.TypeOf((*MockClient)(nil).WatchService), namespace, name)
+}
+
+// GetServicesWithLabels mocks base method
+func (m *MockClient) GetServicesWithLabels(namespace, labelSelectors string) (*v11.ServiceList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServicesWithLabels", namespace, labelSelectors)
+	ret0, _ := ret[0].(*v11.ServiceList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServicesWithLabels indicates an expected call of GetServicesWithLabels
+func (mr *MockClientMockRecorder) GetServicesWithLabels(namespace, labelSelectors interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServicesWithLabels", reflect.TypeOf((*MockClient)(nil).GetServicesWithLabels), namespace, labelSelectors)
+}
+
+// WatchServicesWithLabels mocks base method
+func (m *MockClient) WatchServicesWithLabels(namespace, labelSelectors string) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchServicesWithLabels", namespace, labelSelectors)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchServicesWithLabels indicates an expected call of WatchServicesWithLabels
+func (mr *MockClientMockRecorder) WatchServicesWithLabels(namespace, labelSelectors interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchServicesWithLabels", reflect.TypeOf((*MockClient)(nil).WatchServicesWithLabels), namespace, labelSelectors)
+}
+
+// GetConfigMap mocks base method
+func (m *MockClient) GetConfigMap(namespace, name string) (*v11.ConfigMap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigMap", namespace, name)
+	ret0, _ := ret[0].(*v11.ConfigMap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigMap indicates an expected call of GetConfigMap
+func (mr *MockClientMockRecorder) GetConfigMap(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigMap", reflect.TypeOf((*MockClient)(nil).GetConfigMap), namespace, name)
+}
+
+// WatchConfigMap mocks base method
+func (m *MockClient) WatchConfigMap(namespace, name string) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchConfigMap", namespace, name)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchConfigMap indicates an expected call of WatchConfigMap
+func (mr *MockClientMockRecorder) WatchConfigMap(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchConfigMap", reflect.TypeOf((*MockClient)(nil).WatchConfigMap), namespace, name)
+}
+
+// GetSecret mocks base method
+func (m *MockClient) GetSecret(namespace, name string) (*v11.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecret", namespace, name)
+	ret0, _ := ret[0].(*v11.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecret indicates an expected call of GetSecret
+func (mr *MockClientMockRecorder) GetSecret(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockClient)(nil).GetSecret), namespace, name)
+}
+
+// WatchSecret mocks base method
+func (m *MockClient) WatchSecret(namespace, name string) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchSecret", namespace, name)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchSecret indicates an expected call of WatchSecret
+func (mr *MockClientMockRecorder) WatchSecret(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchSecret", reflect.TypeOf((*MockClient)(nil).WatchSecret), namespace, name)
+}
+
+// GetNodesWithLabels mocks base method
+func (m *MockClient) GetNodesWithLabels(labelSelectors string) (*v11.NodeList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNodesWithLabels", labelSelectors)
+	ret0, _ := ret[0].(*v11.NodeList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNodesWithLabels indicates an expected call of GetNodesWithLabels
+func (mr *MockClientMockRecorder) GetNodesWithLabels(labelSelectors interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNodesWithLabels", reflect.TypeOf((*MockClient)(nil).GetNodesWithLabels), labelSelectors)
+}
+
+// WatchNodesWithLabels mocks base method
+func (m *MockClient) WatchNodesWithLabels(labelSelectors string) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchNodesWithLabels", labelSelectors)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchNodesWithLabels indicates an expected call of WatchNodesWithLabels
+func (mr *MockClientMockRecorder) WatchNodesWithLabels(labelSelectors interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchNodesWithLabels", reflect.TypeOf((*MockClient)(nil).WatchNodesWithLabels), labelSelectors)
+}
+
+// GetEndpointSlices mocks base method
+func (m *MockClient) GetEndpointSlices(namespace, serviceName string) (*v12.EndpointSliceList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEndpointSlices", namespace, serviceName)
+	ret0, _ := ret[0].(*v12.EndpointSliceList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEndpointSlices indicates an expected call of GetEndpointSlices
+func (mr *MockClientMockRecorder) GetEndpointSlices(namespace, serviceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEndpointSlices", reflect.TypeOf((*MockClient)(nil).GetEndpointSlices), namespace, serviceName)
+}
+
+// WatchEndpointSlices mocks base method
+func (m *MockClient) WatchEndpointSlices(namespace, serviceName string) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchEndpointSlices", namespace, serviceName)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchEndpointSlices indicates an expected call of WatchEndpointSlices
+func (mr *MockClientMockRecorder) WatchEndpointSlices(namespace, serviceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchEndpointSlices", reflect.TypeOf((*MockClient)(nil).WatchEndpointSlices), namespace, serviceName)
+}
+
+// GetDeployment mocks base method
+func (m *MockClient) GetDeployment(namespace, name string) (*v1.Deployment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeployment", namespace, name)
+	ret0, _ := ret[0].(*v1.Deployment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeployment indicates an expected call of GetDeployment
+func (mr *MockClientMockRecorder) GetDeployment(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeployment", reflect.TypeOf((*MockClient)(nil).GetDeployment), namespace, name)
+}
+
+// WatchDeployment mocks base method
+func (m *MockClient) WatchDeployment(namespace, name string) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchDeployment", namespace, name)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchDeployment indicates an expected call of WatchDeployment
+func (mr *MockClientMockRecorder) WatchDeployment(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchDeployment", reflect.TypeOf((*MockClient)(nil).WatchDeployment), namespace, name)
+}
+
+// GetStatefulSet mocks base method
+func (m *MockClient) GetStatefulSet(namespace, name string) (*v1.StatefulSet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatefulSet", namespace, name)
+	ret0, _ := ret[0].(*v1.StatefulSet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatefulSet indicates an expected call of GetStatefulSet
+func (mr *MockClientMockRecorder) GetStatefulSet(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatefulSet", reflect.TypeOf((*MockClient)(nil).GetStatefulSet), namespace, name)
+}
+
+// WatchStatefulSet mocks base method
+func (m *MockClient) WatchStatefulSet(namespace, name string) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchStatefulSet", namespace, name)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchStatefulSet indicates an expected call of WatchStatefulSet
+func (mr *MockClientMockRecorder) WatchStatefulSet(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchStatefulSet", reflect.TypeOf((*MockClient)(nil).WatchStatefulSet), namespace, name)
+}
+
+// GetDaemonSet mocks base method
+func (m *MockClient) GetDaemonSet(namespace, name string) (*v1.DaemonSet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDaemonSet", namespace, name)
+	ret0, _ := ret[0].(*v1.DaemonSet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDaemonSet indicates an expected call of GetDaemonSet
+func (mr *MockClientMockRecorder) GetDaemonSet(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDaemonSet", reflect.TypeOf((*MockClient)(nil).GetDaemonSet), namespace, name)
+}
+
+// WatchDaemonSet mocks base method
+func (m *MockClient) WatchDaemonSet(namespace, name string) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchDaemonSet", namespace, name)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchDaemonSet indicates an expected call of WatchDaemonSet
+func (mr *MockClientMockRecorder) WatchDaemonSet(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchDaemonSet", reflect.TypeOf((*MockClient)(nil).WatchDaemonSet), namespace, name)
+}
+
+// GetLease mocks base method
+func (m *MockClient) GetLease(namespace, name string) (*v10.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLease", namespace, name)
+	ret0, _ := ret[0].(*v10.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLease indicates an expected call of GetLease
+func (mr *MockClientMockRecorder) GetLease(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLease", reflect.TypeOf((*MockClient)(nil).GetLease), namespace, name)
+}
+
+// WatchLease mocks base method
+func (m *MockClient) WatchLease(namespace, name string) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchLease", namespace, name)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchLease indicates an expected call of WatchLease
+func (mr *MockClientMockRecorder) WatchLease(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchLease", reflect.TypeOf((*MockClient)(nil).WatchLease), namespace, name)
+}
+
+// GetResource mocks base method
+func (m *MockClient) GetResource(resource schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResource", resource, namespace, name)
+	ret0, _ := ret[0].(*unstructured.Unstructured)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetResource indicates an expected call of GetResource
+func (mr *MockClientMockRecorder) GetResource(resource, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResource", reflect.TypeOf((*MockClient)(nil).GetResource), resource, namespace, name)
+}
+
+// WatchResource mocks base method
+func (m *MockClient) WatchResource(resource schema.GroupVersionResource, namespace, name string) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchResource", resource, namespace, name)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchResource indicates an expected call of WatchResource
+func (mr *MockClientMockRecorder) WatchResource(resource, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchResource", reflect.TypeOf((*MockClient)(nil).WatchResource), resource, namespace, name)
+}
+
+// GetResourcesWithLabels mocks base method
+func (m *MockClient) GetResourcesWithLabels(resource schema.GroupVersionResource, namespace, labelSelectors string) (*unstructured.UnstructuredList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetResourcesWithLabels", resource, namespace, labelSelectors)
+	ret0, _ := ret[0].(*unstructured.UnstructuredList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetResourcesWithLabels indicates an expected call of GetResourcesWithLabels
+func (mr *MockClientMockRecorder) GetResourcesWithLabels(resource, namespace, labelSelectors interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResourcesWithLabels", reflect.TypeOf((*MockClient)(nil).GetResourcesWithLabels), resource, namespace, labelSelectors)
+}
+
+// WatchResourcesWithLabels mocks base method
+func (m *MockClient) WatchResourcesWithLabels(resource schema.GroupVersionResource, namespace, labelSelectors string) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchResourcesWithLabels", resource, namespace, labelSelectors)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchResourcesWithLabels indicates an expected call of WatchResourcesWithLabels
+func (mr *MockClientMockRecorder) WatchResourcesWithLabels(resource, namespace, labelSelectors interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchResourcesWithLabels", reflect.TypeOf((*MockClient)(nil).WatchResourcesWithLabels), resource, namespace, labelSelectors)
+}
+
+// ResourceFor mocks base method
+func (m *MockClient) ResourceFor(apiVersion, kind string) (schema.GroupVersionResource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResourceFor", apiVersion, kind)
+	ret0, _ := ret[0].(schema.GroupVersionResource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResourceFor indicates an expected call of ResourceFor
+func (mr *MockClientMockRecorder) ResourceFor(apiVersion, kind interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResourceFor", reflect.TypeOf((*MockClient)(nil).ResourceFor), apiVersion, kind)
+}
@@ -6,7 +6,12 @@ package mock
 
 import (
 	gomock "github.com/golang/mock/gomock"
-	v1 "k8s.io/api/core/v1"
+	kubernetes "github.com/thecasualcoder/kube-template/pkg/kubernetes"
+	v1 "k8s.io/api/apps/v1"
+	v10 "k8s.io/api/coordination/v1"
+	v11 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	reflect "reflect"
 )
 
@@ -34,10 +39,10 @@ func (m *MockManager) EXPECT() *MockManagerMockRecorder {
 }
 
 // Endpoints mocks base method
-func (m *MockManager) Endpoints(namespace, name string) (*v1.Endpoints, error) {
+func (m *MockManager) Endpoints(namespace, name string) (*v11.Endpoints, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Endpoints", namespace, name)
-	ret0, _ := ret[0].(*v1.Endpoints)
+	ret0, _ := ret[0].(*v11.Endpoints)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -49,10 +54,10 @@ func (mr *MockManagerMockRecorder) Endpoints(namespace, name interface{}) *gomoc
 }
 
 // PodsWithLabels mocks base method
-func (m *MockManager) PodsWithLabels(namespace, labels string) (*v1.PodList, error) {
+func (m *MockManager) PodsWithLabels(namespace, labels string) (*v11.PodList, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "PodsWithLabels", namespace, labels)
-	ret0, _ := ret[0].(*v1.PodList)
+	ret0, _ := ret[0].(*v11.PodList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -63,6 +68,216 @@ func (mr *MockManagerMockRecorder) PodsWithLabels(namespace, labels interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PodsWithLabels", reflect.TypeOf((*MockManager)(nil).PodsWithLabels), namespace, labels)
 }
 
+// Service mocks base method
+func (m *MockManager) Service(namespace, name string) (*v11.Service, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Service", namespace, name)
+	ret0, _ := ret[0].(*v11.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Service indicates an expected call of Service
+func (mr *MockManagerMockRecorder) Service(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Service", reflect.TypeOf((*MockManager)(nil).Service), namespace, name)
+}
+
+// ServicesWithLabels mocks base method
+func (m *MockManager) ServicesWithLabels(namespace, labels string) (*v11.ServiceList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServicesWithLabels", namespace, labels)
+	ret0, _ := ret[0].(*v11.ServiceList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ServicesWithLabels indicates an expected call of ServicesWithLabels
+func (mr *MockManagerMockRecorder) ServicesWithLabels(namespace, labels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServicesWithLabels", reflect.TypeOf((*MockManager)(nil).ServicesWithLabels), namespace, labels)
+}
+
+// ConfigMap mocks base method
+func (m *MockManager) ConfigMap(namespace, name string) (*v11.ConfigMap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfigMap", namespace, name)
+	ret0, _ := ret[0].(*v11.ConfigMap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfigMap indicates an expected call of ConfigMap
+func (mr *MockManagerMockRecorder) ConfigMap(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigMap", reflect.TypeOf((*MockManager)(nil).ConfigMap), namespace, name)
+}
+
+// Secret mocks base method
+func (m *MockManager) Secret(namespace, name string) (*v11.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Secret", namespace, name)
+	ret0, _ := ret[0].(*v11.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Secret indicates an expected call of Secret
+func (mr *MockManagerMockRecorder) Secret(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Secret", reflect.TypeOf((*MockManager)(nil).Secret), namespace, name)
+}
+
+// Nodes mocks base method
+func (m *MockManager) Nodes(labels string) (*v11.NodeList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Nodes", labels)
+	ret0, _ := ret[0].(*v11.NodeList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Nodes indicates an expected call of Nodes
+func (mr *MockManagerMockRecorder) Nodes(labels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Nodes", reflect.TypeOf((*MockManager)(nil).Nodes), labels)
+}
+
+// EndpointSlices mocks base method
+func (m *MockManager) EndpointSlices(namespace, serviceName string) ([]kubernetes.SliceEndpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndpointSlices", namespace, serviceName)
+	ret0, _ := ret[0].([]kubernetes.SliceEndpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndpointSlices indicates an expected call of EndpointSlices
+func (mr *MockManagerMockRecorder) EndpointSlices(namespace, serviceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndpointSlices", reflect.TypeOf((*MockManager)(nil).EndpointSlices), namespace, serviceName)
+}
+
+// Deployment mocks base method
+func (m *MockManager) Deployment(namespace, name string) (*v1.Deployment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deployment", namespace, name)
+	ret0, _ := ret[0].(*v1.Deployment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Deployment indicates an expected call of Deployment
+func (mr *MockManagerMockRecorder) Deployment(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deployment", reflect.TypeOf((*MockManager)(nil).Deployment), namespace, name)
+}
+
+// StatefulSet mocks base method
+func (m *MockManager) StatefulSet(namespace, name string) (*v1.StatefulSet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatefulSet", namespace, name)
+	ret0, _ := ret[0].(*v1.StatefulSet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StatefulSet indicates an expected call of StatefulSet
+func (mr *MockManagerMockRecorder) StatefulSet(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatefulSet", reflect.TypeOf((*MockManager)(nil).StatefulSet), namespace, name)
+}
+
+// DaemonSet mocks base method
+func (m *MockManager) DaemonSet(namespace, name string) (*v1.DaemonSet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DaemonSet", namespace, name)
+	ret0, _ := ret[0].(*v1.DaemonSet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DaemonSet indicates an expected call of DaemonSet
+func (mr *MockManagerMockRecorder) DaemonSet(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DaemonSet", reflect.TypeOf((*MockManager)(nil).DaemonSet), namespace, name)
+}
+
+// Lease mocks base method
+func (m *MockManager) Lease(namespace, name string) (*v10.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lease", namespace, name)
+	ret0, _ := ret[0].(*v10.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lease indicates an expected call of Lease
+func (mr *MockManagerMockRecorder) Lease(namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lease", reflect.TypeOf((*MockManager)(nil).Lease), namespace, name)
+}
+
+// Resource mocks base method
+func (m *MockManager) Resource(resource, namespace, name string) (*unstructured.Unstructured, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resource", resource, namespace, name)
+	ret0, _ := ret[0].(*unstructured.Unstructured)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resource indicates an expected call of Resource
+func (mr *MockManagerMockRecorder) Resource(resource, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resource", reflect.TypeOf((*MockManager)(nil).Resource), resource, namespace, name)
+}
+
+// Resources mocks base method
+func (m *MockManager) Resources(resource, namespace, labels string) (*unstructured.UnstructuredList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resources", resource, namespace, labels)
+	ret0, _ := ret[0].(*unstructured.UnstructuredList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resources indicates an expected call of Resources
+func (mr *MockManagerMockRecorder) Resources(resource, namespace, labels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resources", reflect.TypeOf((*MockManager)(nil).Resources), resource, namespace, labels)
+}
+
+// PodsOf mocks base method
+func (m *MockManager) PodsOf(kind, namespace, name string) (*v11.PodList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PodsOf", kind, namespace, name)
+	ret0, _ := ret[0].(*v11.PodList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PodsOf indicates an expected call of PodsOf
+func (mr *MockManagerMockRecorder) PodsOf(kind, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PodsOf", reflect.TypeOf((*MockManager)(nil).PodsOf), kind, namespace, name)
+}
+
+// Owner mocks base method
+func (m *MockManager) Owner(object v12.Object) (*unstructured.Unstructured, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Owner", object)
+	ret0, _ := ret[0].(*unstructured.Unstructured)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Owner indicates an expected call of Owner
+func (mr *MockManagerMockRecorder) Owner(object interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Owner", reflect.TypeOf((*MockManager)(nil).Owner), object)
+}
+
 // EventChan mocks base method
 func (m *MockManager) EventChan() <-chan struct{} {
 	m.ctrl.T.Helper()
@@ -90,3 +305,17 @@ func (mr *MockManagerMockRecorder) ErrorChan() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ErrorChan", reflect.TypeOf((*MockManager)(nil).ErrorChan))
 }
+
+// PendingKeys mocks base method
+func (m *MockManager) PendingKeys() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingKeys")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// PendingKeys indicates an expected call of PendingKeys
+func (mr *MockManagerMockRecorder) PendingKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingKeys", reflect.TypeOf((*MockManager)(nil).PendingKeys))
+}
@@ -0,0 +1,274 @@
+// Package config describes the kube-template configuration file.
+// The file can be written either in YAML or in HCL.
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+// Config is the top level configuration of kube-template.
+// Durations are written as go duration strings like "2s" or "1m30s".
+type Config struct {
+	// Kubeconfig is the path to the kubeconfig file
+	Kubeconfig string `yaml:"kubeconfig" hcl:"kubeconfig"`
+	// Context is the kubeconfig context to use. Defaults to the current context
+	Context string `yaml:"context" hcl:"context"`
+	// Namespace is used by template functions when their namespace is empty.
+	// Defaults to the namespace of the context or of the pod when running in a cluster
+	Namespace string `yaml:"namespace" hcl:"namespace"`
+	// InCluster uses the service account of the pod instead of a kubeconfig
+	InCluster bool `yaml:"in_cluster" hcl:"in_cluster"`
+	// As is the user to impersonate
+	As string `yaml:"as" hcl:"as"`
+	// AsGroups are the groups to impersonate. Requires As
+	AsGroups []string `yaml:"as_groups" hcl:"as_groups"`
+	// Clusters maps the names of other clusters queried by templates to their kubeconfig contexts.
+	// They share every other setting of the default cluster
+	Clusters map[string]string `yaml:"clusters" hcl:"clusters"`
+	// Wait is used for templates which do not have their own wait
+	Wait *WaitConfig `yaml:"wait" hcl:"wait"`
+	// WatchTemplates re-renders templates whenever their sources change on disk
+	WatchTemplates bool `yaml:"watch_templates" hcl:"watch_templates"`
+	// Log configures logging
+	Log *LogConfig `yaml:"log" hcl:"log"`
+	// Templates to render
+	Templates []*TemplateConfig `yaml:"templates" hcl:"-"`
+	// Exec is the child process to run once templates are rendered
+	Exec *ExecConfig `yaml:"exec" hcl:"exec"`
+}
+
+// TemplateConfig describes a single template to render.
+type TemplateConfig struct {
+	// Source is the path to the go-template. "-" means STDIN
+	Source string `yaml:"source" hcl:"source"`
+	// Contents is an inline go-template used instead of Source
+	Contents string `yaml:"contents" hcl:"contents"`
+	// Destination is the path the template is rendered to. "-" means STDOUT
+	Destination string `yaml:"destination" hcl:"destination"`
+	// Perms is the octal file mode of the destination, like "0644".
+	// Defaults to the mode of an existing destination or 0644
+	Perms string `yaml:"perms" hcl:"perms"`
+	// UID is the owner of the destination. Defaults to the user running kube-template
+	UID *int `yaml:"uid" hcl:"uid"`
+	// GID is the group of the destination. Defaults to the group of the user running kube-template
+	GID *int `yaml:"gid" hcl:"gid"`
+	// Fsync flushes the destination to disk after every write
+	Fsync bool `yaml:"fsync" hcl:"fsync"`
+	// Overwrite allows the destination to exist when kube-template starts
+	Overwrite bool `yaml:"overwrite" hcl:"overwrite"`
+	// KeepOnExit keeps the destination when kube-template exits instead of removing it
+	KeepOnExit bool `yaml:"keep_on_exit" hcl:"keep_on_exit"`
+	// Backups is the number of previous versions of the destination to keep.
+	// The latest one is <destination>.bak and older ones are <destination>.bak.1, <destination>.bak.2 and so on
+	Backups int `yaml:"backups" hcl:"backups"`
+	// Command is run through the shell after the destination is written
+	Command string `yaml:"command" hcl:"command"`
+	// CommandTimeout is the time after which the command is killed. Defaults to 30s
+	CommandTimeout string `yaml:"command_timeout" hcl:"command_timeout"`
+	// OnCommandFailure is one of ignore, retry or stop. Defaults to ignore
+	OnCommandFailure string `yaml:"on_command_failure" hcl:"on_command_failure"`
+	// CommandRetries is the number of retries when OnCommandFailure is retry. Defaults to 3
+	CommandRetries *int `yaml:"command_retries" hcl:"command_retries"`
+	// Wait overrides the top level wait for this template
+	Wait *WaitConfig `yaml:"wait" hcl:"wait"`
+}
+
+// WaitConfig is the quiescence window of a template.
+// A template is written once there have been no changes for Min,
+// but never later than Max after the first change.
+// In YAML it can also be written in the short form "min" or "min:max".
+type WaitConfig struct {
+	// Min is the time without changes after which the template is written
+	Min string `yaml:"min" hcl:"min"`
+	// Max is the longest time a write is postponed. Defaults to 4 times Min
+	Max string `yaml:"max" hcl:"max"`
+}
+
+// ParseWait parses the short form of a wait, "min" or "min:max".
+func ParseWait(value string) (*WaitConfig, error) {
+	parts := strings.Split(value, ":")
+	switch len(parts) {
+	case 1:
+		return &WaitConfig{Min: parts[0]}, nil
+	case 2:
+		return &WaitConfig{Min: parts[0], Max: parts[1]}, nil
+	default:
+		return nil, fmt.Errorf("wait \"%s\" should be of the format min or min:max", value)
+	}
+}
+
+// UnmarshalYAML accepts both the short form and a mapping with min and max
+func (w *WaitConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var short string
+	if err := unmarshal(&short); err == nil {
+		wait, err := ParseWait(short)
+		if err != nil {
+			return err
+		}
+		*w = *wait
+		return nil
+	}
+
+	type plain WaitConfig
+	return unmarshal((*plain)(w))
+}
+
+// ExecConfig describes a child process supervised by kube-template.
+type ExecConfig struct {
+	// Command to run along with its arguments. Arguments are split like a shell does
+	Command string `yaml:"command" hcl:"command"`
+	// ReloadSignal is sent to the process when a template changes.
+	// The process is restarted if it is empty
+	ReloadSignal string `yaml:"reload_signal" hcl:"reload_signal"`
+	// KillSignal is sent to the process to stop it. Defaults to SIGINT
+	KillSignal string `yaml:"kill_signal" hcl:"kill_signal"`
+	// KillTimeout is the time to wait for the process to exit after KillSignal is sent
+	KillTimeout string `yaml:"kill_timeout" hcl:"kill_timeout"`
+}
+
+// LogConfig configures logging.
+type LogConfig struct {
+	// Level is one of debug, info, warn or error
+	Level string `yaml:"level" hcl:"level"`
+}
+
+// Parse reads the configuration file at path.
+// Files with a .hcl extension are parsed as HCL, everything else as YAML.
+func Parse(fs afero.Fs, path string) (*Config, error) {
+	contents, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+
+	config := &Config{}
+	if strings.ToLower(filepath.Ext(path)) == ".hcl" {
+		err = parseHCL(contents, config)
+	} else {
+		err = yaml.UnmarshalStrict(contents, config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// parseHCL decodes every template block separately
+// since hcl does not decode repeated blocks into a slice of structs correctly.
+func parseHCL(contents []byte, config *Config) error {
+	file, err := hcl.ParseBytes(contents)
+	if err != nil {
+		return err
+	}
+
+	if err := hcl.DecodeObject(config, file.Node); err != nil {
+		return err
+	}
+
+	list, ok := file.Node.(*ast.ObjectList)
+	if !ok {
+		return fmt.Errorf("config file does not contain a root object")
+	}
+
+	rootKeys := hclKeys(reflect.TypeOf(Config{}))
+	rootKeys["template"] = reflect.TypeOf(TemplateConfig{})
+	if err := checkHCLKeys(list, rootKeys, ""); err != nil {
+		return err
+	}
+
+	for _, item := range list.Filter("template").Items {
+		template := &TemplateConfig{}
+		if err := hcl.DecodeObject(template, item.Val); err != nil {
+			return err
+		}
+		config.Templates = append(config.Templates, template)
+	}
+	return nil
+}
+
+// checkHCLKeys rejects keys which are not in keys, like yaml.UnmarshalStrict does,
+// since hcl ignores unknown keys while decoding.
+// Blocks are checked against the keys of the struct they are decoded into.
+func checkHCLKeys(list *ast.ObjectList, keys map[string]reflect.Type, path string) error {
+	for _, item := range list.Items {
+		key := fmt.Sprint(item.Keys[0].Token.Value())
+		fieldType, ok := keys[key]
+		if !ok {
+			return fmt.Errorf("unknown key %s%s", path, key)
+		}
+
+		object, ok := item.Val.(*ast.ObjectType)
+		if !ok {
+			continue
+		}
+		for fieldType.Kind() == reflect.Ptr || fieldType.Kind() == reflect.Slice {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() != reflect.Struct {
+			continue
+		}
+		if err := checkHCLKeys(object.List, hclKeys(fieldType), path+key+"."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hclKeys returns the types of the fields of a struct by their hcl key
+func hclKeys(structType reflect.Type) map[string]reflect.Type {
+	keys := map[string]reflect.Type{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		key := strings.Split(field.Tag.Get("hcl"), ",")[0]
+		if key != "" && key != "-" {
+			keys[key] = field.Type
+		}
+	}
+	return keys
+}
+
+// Validate checks that required values are present.
+func (c *Config) Validate() error {
+	for name, context := range c.Clusters {
+		if name == "" || strings.HasPrefix(name, "@") {
+			return fmt.Errorf("clusters: invalid cluster name %q", name)
+		}
+		if context == "" {
+			return fmt.Errorf("clusters: context of cluster %s is required", name)
+		}
+	}
+
+	for i, template := range c.Templates {
+		if template == nil {
+			return fmt.Errorf("templates[%d] is empty", i)
+		}
+		if template.Source == "" && template.Contents == "" {
+			return fmt.Errorf("templates[%d]: source or contents is required", i)
+		}
+		if template.Source != "" && template.Contents != "" {
+			return fmt.Errorf("templates[%d]: source and contents cannot be used together", i)
+		}
+		if template.Destination == "" {
+			return fmt.Errorf("templates[%d]: destination is required", i)
+		}
+		if template.Backups < 0 {
+			return fmt.Errorf("templates[%d]: backups cannot be negative", i)
+		}
+	}
+
+	if c.Exec != nil && c.Exec.Command == "" {
+		return fmt.Errorf("exec: command is required")
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	retries := 5
+	expected := &Config{
+		Kubeconfig:     "/etc/kubeconfig",
+		Context:        "production",
+		Clusters:       map[string]string{"eu-west": "eu-west-production"},
+		Wait:           &WaitConfig{Min: "3s", Max: "10s"},
+		WatchTemplates: true,
+		Log:            &LogConfig{Level: "debug"},
+		Templates: []*TemplateConfig{
+			{
+				Source:           "/templates/nginx.tmpl",
+				Destination:      "/etc/nginx/upstreams.conf",
+				Perms:            "0640",
+				Command:          "nginx -s reload",
+				CommandTimeout:   "10s",
+				OnCommandFailure: "retry",
+				CommandRetries:   &retries,
+				Wait:             &WaitConfig{Min: "5s"},
+			},
+			{
+				Source:      "/templates/peers.tmpl",
+				Destination: "/etc/peers.json",
+			},
+		},
+		Exec: &ExecConfig{
+			Command:     "nginx -g 'daemon off;'",
+			KillTimeout: "10s",
+		},
+	}
+
+	t.Run("should parse yaml config", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "config.yaml", []byte(`
+kubeconfig: /etc/kubeconfig
+context: production
+clusters:
+  eu-west: eu-west-production
+wait:
+  min: 3s
+  max: 10s
+watch_templates: true
+log:
+  level: debug
+templates:
+  - source: /templates/nginx.tmpl
+    destination: /etc/nginx/upstreams.conf
+    perms: "0640"
+    command: nginx -s reload
+    command_timeout: 10s
+    on_command_failure: retry
+    command_retries: 5
+    wait: 5s
+  - source: /templates/peers.tmpl
+    destination: /etc/peers.json
+exec:
+  command: nginx -g 'daemon off;'
+  kill_timeout: 10s
+`), 0644)
+
+		config, err := Parse(fs, "config.yaml")
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, config)
+	})
+
+	t.Run("should parse hcl config", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "config.hcl", []byte(`
+kubeconfig      = "/etc/kubeconfig"
+context         = "production"
+watch_templates = true
+
+clusters {
+  eu-west = "eu-west-production"
+}
+
+wait {
+  min = "3s"
+  max = "10s"
+}
+
+log {
+  level = "debug"
+}
+
+template {
+  source      = "/templates/nginx.tmpl"
+  destination = "/etc/nginx/upstreams.conf"
+  perms       = "0640"
+  command     = "nginx -s reload"
+
+  wait {
+    min = "5s"
+  }
+
+  command_timeout    = "10s"
+  on_command_failure = "retry"
+  command_retries    = 5
+}
+
+template {
+  source      = "/templates/peers.tmpl"
+  destination = "/etc/peers.json"
+}
+
+exec {
+  command      = "nginx -g 'daemon off;'"
+  kill_timeout = "10s"
+}
+`), 0644)
+
+		config, err := Parse(fs, "config.hcl")
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, config)
+	})
+
+	t.Run("should parse short form of wait in yaml", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "config.yaml", []byte("wait: 2s:10s"), 0644)
+
+		config, err := Parse(fs, "config.yaml")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, &WaitConfig{Min: "2s", Max: "10s"}, config.Wait)
+		}
+	})
+
+	t.Run("should return error for unknown keys", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "config.yaml", []byte("tempaltes: []"), 0644)
+
+		_, err := Parse(fs, "config.yaml")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should return error for unknown hcl keys", func(t *testing.T) {
+		for contents, key := range map[string]string{
+			`kubeconifg = "/etc/kubeconfig"`:                             "kubeconifg",
+			"wait {\n  minimum = \"2s\"\n}":                              "wait.minimum",
+			"template {\n  source = \"a.tmpl\"\n  destnation = \"a\"\n}": "template.destnation",
+		} {
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, "config.hcl", []byte(contents), 0644)
+
+			_, err := Parse(fs, "config.hcl")
+
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), "unknown key "+key)
+			}
+		}
+	})
+
+	t.Run("should return error if template has no destination", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "config.yaml", []byte("templates:\n  - source: a.tmpl"), 0644)
+
+		_, err := Parse(fs, "config.yaml")
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "templates[0]: destination is required")
+		}
+	})
+
+	t.Run("should return error if template has both source and contents", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "config.yaml", []byte("templates:\n  - source: a.tmpl\n    contents: a\n    destination: a.conf"), 0644)
+
+		_, err := Parse(fs, "config.yaml")
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "templates[0]: source and contents cannot be used together")
+		}
+	})
+
+	t.Run("should return error if cluster has no context", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		_ = afero.WriteFile(fs, "config.yaml", []byte("clusters:\n  eu-west: \"\""), 0644)
+
+		_, err := Parse(fs, "config.yaml")
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "clusters: context of cluster eu-west is required")
+		}
+	})
+
+	t.Run("should return error if file does not exist", func(t *testing.T) {
+		_, err := Parse(afero.NewMemMapFs(), "config.yaml")
+
+		assert.Error(t, err)
+	})
+}
@@ -1,34 +1,183 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	appsV1 "k8s.io/api/apps/v1"
+	coordinationV1 "k8s.io/api/coordination/v1"
 	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"os"
+	"strings"
+	"sync"
 )
 
-// NewClient creates a clientset for given kubeconfig file.
-// If kubeconfig is empty, it creates a InClusterClient.
+// Config holds the settings used to connect to a cluster
+type Config struct {
+	// Kubeconfig is the path to the kubeconfig file.
+	// Empty means $KUBECONFIG or ~/.kube/config, falling back to the in-cluster config if neither exist
+	Kubeconfig string
+	// Context is the kubeconfig context to use. Empty means the current context
+	Context string
+	// Namespace is used when a namespace argument is empty.
+	// Empty means the namespace of the context or of the pod when running in a cluster
+	Namespace string
+	// InCluster uses the service account of the pod instead of a kubeconfig
+	InCluster bool
+	// As is the user to impersonate
+	As string
+	// AsGroups are the groups to impersonate
+	AsGroups []string
+}
+
+// NewClient creates a clientset for given kubeconfig file and context.
+// If InCluster is set, it creates a InClusterClient.
 // Errors out if client cannot be created.
-func NewClient(kubeconfig string) (Client, error) {
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+func NewClient(config Config) (Client, error) {
+	if len(config.AsGroups) != 0 && config.As == "" {
+		return nil, fmt.Errorf("impersonating groups requires a user to impersonate")
+	}
+
+	restConfig, namespace, err := restConfig(config)
 	if err != nil {
 		return nil, err
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
+	clientset, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return nil, err
 	}
-	return &clientImpl{clientset}, nil
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	currentContext, err := contextName(config)
+	if err != nil {
+		return nil, err
+	}
+	return &clientImpl{
+		Clientset:  clientset,
+		dynamic:    dynamicClient,
+		context:    currentContext,
+		namespace:  namespace,
+		namespaced: &sync.Map{},
+		resources:  &sync.Map{},
+	}, nil
+}
+
+// restConfig returns the config to connect with along with the default namespace
+func restConfig(config Config) (*rest.Config, string, error) {
+	if config.InCluster {
+		if config.Kubeconfig != "" || config.Context != "" {
+			return nil, "", fmt.Errorf("in-cluster config cannot be used along with a kubeconfig or a context")
+		}
+
+		restConfig, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, "", fmt.Errorf("error loading in-cluster config: %w", err)
+		}
+		if config.As != "" {
+			restConfig.Impersonate = rest.ImpersonationConfig{
+				UserName: config.As,
+				Groups:   config.AsGroups,
+			}
+		}
+
+		namespace := config.Namespace
+		if namespace == "" {
+			namespace = inClusterNamespace()
+		}
+		return restConfig, namespace, nil
+	}
+
+	clientConfig := loadKubeconfig(config)
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("error loading kubeconfig: %w", err)
+	}
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, "", fmt.Errorf("error loading namespace from kubeconfig: %w", err)
+	}
+	return restConfig, namespace, nil
+}
+
+// loadKubeconfig loads the kubeconfig the way kubectl does, overridden by config.
+// The user and groups to impersonate only override the kubeconfig ones when they are set.
+func loadKubeconfig(config Config) clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = config.Kubeconfig
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		loadingRules,
+		&clientcmd.ConfigOverrides{
+			CurrentContext: config.Context,
+			Context:        clientcmdapi.Context{Namespace: config.Namespace},
+			AuthInfo: clientcmdapi.AuthInfo{
+				Impersonate:       config.As,
+				ImpersonateGroups: config.AsGroups,
+			},
+		},
+	)
+}
+
+// InClusterContext is the context of clients using the in-cluster config
+const InClusterContext = "in-cluster"
+
+// contextName is the kubeconfig context config connects with, which is the current context if it is empty
+func contextName(config Config) (string, error) {
+	if config.InCluster {
+		return InClusterContext, nil
+	}
+	if config.Context != "" {
+		return config.Context, nil
+	}
+
+	rawConfig, err := loadKubeconfig(config).RawConfig()
+	if err != nil {
+		return "", fmt.Errorf("error loading kubeconfig: %w", err)
+	}
+	return rawConfig.CurrentContext, nil
+}
+
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// inClusterNamespace is the namespace of the pod kube-template runs in.
+// It is looked up the same way clientcmd does.
+func inClusterNamespace() string {
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+
+	if data, err := ioutil.ReadFile(serviceAccountNamespaceFile); err == nil {
+		if namespace := strings.TrimSpace(string(data)); namespace != "" {
+			return namespace
+		}
+	}
+	return metaV1.NamespaceDefault
 }
 
 // Client represents a Kubernetes client. It abstracts and proxies call to kubernetes API.
 // Make sure to return Kubernetes objects always so that it remains as a proxy with few abstractions
 type Client interface {
+	// Namespace is the default namespace, to be used when a namespace argument is empty
+	Namespace() string
+	// Context is the kubeconfig context the client connects with, InClusterContext for the in-cluster config
+	Context() string
 	// GetEndpoints fetches the endpoints for a given namespace and name
 	GetEndpoints(namespace, name string) (*v1.Endpoints, error)
 	// WatchEndpoints returns a watcher of Endpoints watch API
@@ -39,30 +188,306 @@ type Client interface {
 	// WatchPodsWithLabels watches pods for given namespace and label selectors
 	// Label selectors need to sent in the format of key=value,key2=value2
 	WatchPodsWithLabels(namespace, labelSelectors string) (watch.Interface, error)
+	// GetService fetches the service for a given namespace and name
+	GetService(namespace, name string) (*v1.Service, error)
+	// WatchService returns a watcher of Service watch API
+	WatchService(namespace, name string) (watch.Interface, error)
+	// GetServicesWithLabels fetches service list for given namespace and label selectors
+	// Label selectors need to sent in the format of key=value,key2=value2
+	GetServicesWithLabels(namespace, labelSelectors string) (*v1.ServiceList, error)
+	// WatchServicesWithLabels watches services for given namespace and label selectors
+	// Label selectors need to sent in the format of key=value,key2=value2
+	WatchServicesWithLabels(namespace, labelSelectors string) (watch.Interface, error)
+	// GetConfigMap fetches the config map for a given namespace and name
+	GetConfigMap(namespace, name string) (*v1.ConfigMap, error)
+	// WatchConfigMap returns a watcher of ConfigMap watch API
+	WatchConfigMap(namespace, name string) (watch.Interface, error)
+	// GetSecret fetches the secret for a given namespace and name
+	GetSecret(namespace, name string) (*v1.Secret, error)
+	// WatchSecret returns a watcher of Secret watch API
+	WatchSecret(namespace, name string) (watch.Interface, error)
+	// GetNodesWithLabels fetches node list for given label selectors
+	// Label selectors need to sent in the format of key=value,key2=value2
+	GetNodesWithLabels(labelSelectors string) (*v1.NodeList, error)
+	// WatchNodesWithLabels watches nodes for given label selectors
+	// Label selectors need to sent in the format of key=value,key2=value2
+	WatchNodesWithLabels(labelSelectors string) (watch.Interface, error)
+	// GetEndpointSlices fetches the endpoint slices of a service for a given namespace and service name
+	GetEndpointSlices(namespace, serviceName string) (*discovery.EndpointSliceList, error)
+	// WatchEndpointSlices watches the endpoint slices of a service for a given namespace and service name
+	WatchEndpointSlices(namespace, serviceName string) (watch.Interface, error)
+	// GetDeployment fetches the deployment for a given namespace and name
+	GetDeployment(namespace, name string) (*appsV1.Deployment, error)
+	// WatchDeployment returns a watcher of Deployment watch API
+	WatchDeployment(namespace, name string) (watch.Interface, error)
+	// GetStatefulSet fetches the stateful set for a given namespace and name
+	GetStatefulSet(namespace, name string) (*appsV1.StatefulSet, error)
+	// WatchStatefulSet returns a watcher of StatefulSet watch API
+	WatchStatefulSet(namespace, name string) (watch.Interface, error)
+	// GetDaemonSet fetches the daemon set for a given namespace and name
+	GetDaemonSet(namespace, name string) (*appsV1.DaemonSet, error)
+	// WatchDaemonSet returns a watcher of DaemonSet watch API
+	WatchDaemonSet(namespace, name string) (watch.Interface, error)
+	// GetLease fetches the lease for a given namespace and name
+	GetLease(namespace, name string) (*coordinationV1.Lease, error)
+	// WatchLease returns a watcher of Lease watch API
+	WatchLease(namespace, name string) (watch.Interface, error)
+	// GetResource fetches an object of any resource, including custom resources, for a given namespace and name.
+	// Namespace is ignored for cluster scoped resources
+	GetResource(resource schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error)
+	// WatchResource returns a watcher of an object of any resource for a given namespace and name
+	WatchResource(resource schema.GroupVersionResource, namespace, name string) (watch.Interface, error)
+	// GetResourcesWithLabels fetches the objects of any resource for given namespace and label selectors
+	// Label selectors need to sent in the format of key=value,key2=value2
+	GetResourcesWithLabels(resource schema.GroupVersionResource, namespace, labelSelectors string) (*unstructured.UnstructuredList, error)
+	// WatchResourcesWithLabels watches the objects of any resource for given namespace and label selectors
+	// Label selectors need to sent in the format of key=value,key2=value2
+	WatchResourcesWithLabels(resource schema.GroupVersionResource, namespace, labelSelectors string) (watch.Interface, error)
+	// ResourceFor looks up the resource of a kind, like apps/v1 ReplicaSet, through the discovery API
+	ResourceFor(apiVersion, kind string) (schema.GroupVersionResource, error)
 }
 
 type clientImpl struct {
 	*kubernetes.Clientset
+	dynamic   dynamic.Interface
+	context   string
+	namespace string
+	// namespaced caches whether a schema.GroupVersionResource is namespaced
+	namespaced *sync.Map
+	// resources caches the schema.GroupVersionResource of a schema.GroupVersionKind
+	resources *sync.Map
+}
+
+func (c clientImpl) Namespace() string {
+	return c.namespace
+}
+
+func (c clientImpl) Context() string {
+	return c.context
 }
 
 func (c clientImpl) GetEndpoints(namespace, name string) (*v1.Endpoints, error) {
-	return c.CoreV1().Endpoints(namespace).Get(name, metaV1.GetOptions{})
+	return c.CoreV1().Endpoints(namespace).Get(context.Background(), name, metaV1.GetOptions{})
 }
 
 func (c clientImpl) WatchEndpoints(namespace, name string) (watch.Interface, error) {
-	return c.CoreV1().Endpoints(namespace).Watch(metaV1.ListOptions{
+	return c.CoreV1().Endpoints(namespace).Watch(context.Background(), metaV1.ListOptions{
 		FieldSelector: fmt.Sprintf("metadata.name=%s", name),
 	})
 }
 
 func (c clientImpl) GetPodsWithLabels(namespace, labelSelectors string) (*v1.PodList, error) {
-	return c.CoreV1().Pods(namespace).List(metaV1.ListOptions{
+	return c.CoreV1().Pods(namespace).List(context.Background(), metaV1.ListOptions{
 		LabelSelector: labelSelectors,
 	})
 }
 
 func (c clientImpl) WatchPodsWithLabels(namespace, labelSelectors string) (watch.Interface, error) {
-	return c.CoreV1().Pods(namespace).Watch(metaV1.ListOptions{
+	return c.CoreV1().Pods(namespace).Watch(context.Background(), metaV1.ListOptions{
 		LabelSelector: labelSelectors,
 	})
 }
+
+func (c clientImpl) GetService(namespace, name string) (*v1.Service, error) {
+	return c.CoreV1().Services(namespace).Get(context.Background(), name, metaV1.GetOptions{})
+}
+
+func (c clientImpl) WatchService(namespace, name string) (watch.Interface, error) {
+	return c.CoreV1().Services(namespace).Watch(context.Background(), metaV1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%s", name),
+	})
+}
+
+func (c clientImpl) GetServicesWithLabels(namespace, labelSelectors string) (*v1.ServiceList, error) {
+	return c.CoreV1().Services(namespace).List(context.Background(), metaV1.ListOptions{
+		LabelSelector: labelSelectors,
+	})
+}
+
+func (c clientImpl) WatchServicesWithLabels(namespace, labelSelectors string) (watch.Interface, error) {
+	return c.CoreV1().Services(namespace).Watch(context.Background(), metaV1.ListOptions{
+		LabelSelector: labelSelectors,
+	})
+}
+
+func (c clientImpl) GetConfigMap(namespace, name string) (*v1.ConfigMap, error) {
+	return c.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metaV1.GetOptions{})
+}
+
+func (c clientImpl) WatchConfigMap(namespace, name string) (watch.Interface, error) {
+	return c.CoreV1().ConfigMaps(namespace).Watch(context.Background(), metaV1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%s", name),
+	})
+}
+
+func (c clientImpl) GetSecret(namespace, name string) (*v1.Secret, error) {
+	return c.CoreV1().Secrets(namespace).Get(context.Background(), name, metaV1.GetOptions{})
+}
+
+func (c clientImpl) WatchSecret(namespace, name string) (watch.Interface, error) {
+	return c.CoreV1().Secrets(namespace).Watch(context.Background(), metaV1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%s", name),
+	})
+}
+
+func (c clientImpl) GetNodesWithLabels(labelSelectors string) (*v1.NodeList, error) {
+	return c.CoreV1().Nodes().List(context.Background(), metaV1.ListOptions{
+		LabelSelector: labelSelectors,
+	})
+}
+
+func (c clientImpl) WatchNodesWithLabels(labelSelectors string) (watch.Interface, error) {
+	return c.CoreV1().Nodes().Watch(context.Background(), metaV1.ListOptions{
+		LabelSelector: labelSelectors,
+	})
+}
+
+func (c clientImpl) GetEndpointSlices(namespace, serviceName string) (*discovery.EndpointSliceList, error) {
+	return c.DiscoveryV1().EndpointSlices(namespace).List(context.Background(), metaV1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", discovery.LabelServiceName, serviceName),
+	})
+}
+
+func (c clientImpl) WatchEndpointSlices(namespace, serviceName string) (watch.Interface, error) {
+	return c.DiscoveryV1().EndpointSlices(namespace).Watch(context.Background(), metaV1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", discovery.LabelServiceName, serviceName),
+	})
+}
+
+func (c clientImpl) GetDeployment(namespace, name string) (*appsV1.Deployment, error) {
+	return c.AppsV1().Deployments(namespace).Get(context.Background(), name, metaV1.GetOptions{})
+}
+
+func (c clientImpl) WatchDeployment(namespace, name string) (watch.Interface, error) {
+	return c.AppsV1().Deployments(namespace).Watch(context.Background(), metaV1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%s", name),
+	})
+}
+
+func (c clientImpl) GetStatefulSet(namespace, name string) (*appsV1.StatefulSet, error) {
+	return c.AppsV1().StatefulSets(namespace).Get(context.Background(), name, metaV1.GetOptions{})
+}
+
+func (c clientImpl) WatchStatefulSet(namespace, name string) (watch.Interface, error) {
+	return c.AppsV1().StatefulSets(namespace).Watch(context.Background(), metaV1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%s", name),
+	})
+}
+
+func (c clientImpl) GetDaemonSet(namespace, name string) (*appsV1.DaemonSet, error) {
+	return c.AppsV1().DaemonSets(namespace).Get(context.Background(), name, metaV1.GetOptions{})
+}
+
+func (c clientImpl) WatchDaemonSet(namespace, name string) (watch.Interface, error) {
+	return c.AppsV1().DaemonSets(namespace).Watch(context.Background(), metaV1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%s", name),
+	})
+}
+
+func (c clientImpl) GetLease(namespace, name string) (*coordinationV1.Lease, error) {
+	return c.CoordinationV1().Leases(namespace).Get(context.Background(), name, metaV1.GetOptions{})
+}
+
+func (c clientImpl) WatchLease(namespace, name string) (watch.Interface, error) {
+	return c.CoordinationV1().Leases(namespace).Watch(context.Background(), metaV1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%s", name),
+	})
+}
+
+func (c clientImpl) GetResource(resource schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	resourceInterface, err := c.resourceInterface(resource, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return resourceInterface.Get(context.Background(), name, metaV1.GetOptions{})
+}
+
+func (c clientImpl) WatchResource(resource schema.GroupVersionResource, namespace, name string) (watch.Interface, error) {
+	resourceInterface, err := c.resourceInterface(resource, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return resourceInterface.Watch(context.Background(), metaV1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%s", name),
+	})
+}
+
+func (c clientImpl) GetResourcesWithLabels(resource schema.GroupVersionResource, namespace, labelSelectors string) (*unstructured.UnstructuredList, error) {
+	resourceInterface, err := c.resourceInterface(resource, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return resourceInterface.List(context.Background(), metaV1.ListOptions{
+		LabelSelector: labelSelectors,
+	})
+}
+
+func (c clientImpl) WatchResourcesWithLabels(resource schema.GroupVersionResource, namespace, labelSelectors string) (watch.Interface, error) {
+	resourceInterface, err := c.resourceInterface(resource, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return resourceInterface.Watch(context.Background(), metaV1.ListOptions{
+		LabelSelector: labelSelectors,
+	})
+}
+
+func (c clientImpl) ResourceFor(apiVersion, kind string) (schema.GroupVersionResource, error) {
+	groupVersion, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	groupVersionKind := groupVersion.WithKind(kind)
+	if resource, ok := c.resources.Load(groupVersionKind); ok {
+		return resource.(schema.GroupVersionResource), nil
+	}
+
+	resourceList, err := c.Discovery().ServerResourcesForGroupVersion(apiVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, fmt.Errorf("error discovering resources of %s: %w", apiVersion, err)
+	}
+
+	for _, apiResource := range resourceList.APIResources {
+		// subresources like deployments/scale share the kind of their resource
+		if apiResource.Kind == kind && !strings.Contains(apiResource.Name, "/") {
+			resource := groupVersion.WithResource(apiResource.Name)
+			c.resources.Store(groupVersionKind, resource)
+			return resource, nil
+		}
+	}
+	return schema.GroupVersionResource{}, fmt.Errorf("kind %s not found in %s", kind, apiVersion)
+}
+
+// resourceInterface returns the dynamic client of resource, scoped to namespace if resource is namespaced
+func (c clientImpl) resourceInterface(resource schema.GroupVersionResource, namespace string) (dynamic.ResourceInterface, error) {
+	namespaced, err := c.isNamespaced(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	if !namespaced {
+		return c.dynamic.Resource(resource), nil
+	}
+	return c.dynamic.Resource(resource).Namespace(namespace), nil
+}
+
+// isNamespaced looks up the scope of resource through the discovery API.
+// The result is cached as the scope of a resource does not change.
+func (c clientImpl) isNamespaced(resource schema.GroupVersionResource) (bool, error) {
+	if namespaced, ok := c.namespaced.Load(resource); ok {
+		return namespaced.(bool), nil
+	}
+
+	resourceList, err := c.Discovery().ServerResourcesForGroupVersion(resource.GroupVersion().String())
+	if err != nil {
+		return false, fmt.Errorf("error discovering resources of %s: %w", resource.GroupVersion(), err)
+	}
+
+	for _, apiResource := range resourceList.APIResources {
+		if apiResource.Name == resource.Resource {
+			c.namespaced.Store(resource, apiResource.Namespaced)
+			return apiResource.Namespaced, nil
+		}
+	}
+	return false, fmt.Errorf("resource %s not found in %s", resource.Resource, resource.GroupVersion())
+}
@@ -0,0 +1,146 @@
+package kubernetes
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+current-context: staging
+clusters:
+- name: staging
+  cluster:
+    server: https://staging.example.com
+- name: production
+  cluster:
+    server: https://production.example.com
+users:
+- name: admin
+  user:
+    token: secret
+- name: auditor
+  user:
+    token: secret
+    as: auditor
+    as-groups:
+    - audit
+contexts:
+- name: staging
+  context:
+    cluster: staging
+    user: admin
+- name: production
+  context:
+    cluster: production
+    user: admin
+    namespace: web
+- name: audit
+  context:
+    cluster: production
+    user: auditor
+`
+
+func TestRestConfig(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "kube-template")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "kubeconfig")
+	_ = ioutil.WriteFile(path, []byte(kubeconfig), 0600)
+
+	t.Run("should use the current context and the default namespace", func(t *testing.T) {
+		restConfig, namespace, err := restConfig(Config{Kubeconfig: path})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "https://staging.example.com", restConfig.Host)
+			assert.Equal(t, "default", namespace)
+		}
+	})
+
+	t.Run("should use the namespace of the given context", func(t *testing.T) {
+		restConfig, namespace, err := restConfig(Config{Kubeconfig: path, Context: "production"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "https://production.example.com", restConfig.Host)
+			assert.Equal(t, "web", namespace)
+		}
+	})
+
+	t.Run("should override the namespace of the context", func(t *testing.T) {
+		_, namespace, err := restConfig(Config{Kubeconfig: path, Context: "production", Namespace: "api"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "api", namespace)
+		}
+	})
+
+	t.Run("should impersonate the user and groups of the kubeconfig", func(t *testing.T) {
+		restConfig, _, err := restConfig(Config{Kubeconfig: path, Context: "audit"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "auditor", restConfig.Impersonate.UserName)
+			assert.Equal(t, []string{"audit"}, restConfig.Impersonate.Groups)
+		}
+	})
+
+	t.Run("should override the impersonated user and groups of the kubeconfig", func(t *testing.T) {
+		restConfig, _, err := restConfig(Config{Kubeconfig: path, Context: "audit", As: "jane", AsGroups: []string{"developers"}})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "jane", restConfig.Impersonate.UserName)
+			assert.Equal(t, []string{"developers"}, restConfig.Impersonate.Groups)
+		}
+	})
+
+	t.Run("should return error if the context does not exist", func(t *testing.T) {
+		_, _, err := restConfig(Config{Kubeconfig: path, Context: "development"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should return error if in-cluster is used along with a kubeconfig", func(t *testing.T) {
+		_, _, err := restConfig(Config{Kubeconfig: path, InCluster: true})
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "in-cluster config cannot be used along with a kubeconfig")
+		}
+	})
+}
+
+func TestContextName(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "kube-template")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "kubeconfig")
+	_ = ioutil.WriteFile(path, []byte(kubeconfig), 0600)
+
+	t.Run("should use the current context by default", func(t *testing.T) {
+		context, err := contextName(Config{Kubeconfig: path})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "staging", context)
+	})
+
+	t.Run("should use the given context", func(t *testing.T) {
+		context, err := contextName(Config{Kubeconfig: path, Context: "production"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "production", context)
+	})
+
+	t.Run("should name the in-cluster config", func(t *testing.T) {
+		context, err := contextName(Config{InCluster: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, InClusterContext, context)
+	})
+}
+
+func TestNewClient(t *testing.T) {
+	t.Run("should return error if groups are impersonated without a user", func(t *testing.T) {
+		_, err := NewClient(Config{AsGroups: []string{"system:masters"}})
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,116 @@
+package kubernetes
+
+import (
+	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	"sort"
+	"strings"
+)
+
+// SliceEndpoint is an endpoint of a service along with the ports of the endpoint slice it belongs to.
+type SliceEndpoint struct {
+	// Addresses of the endpoint. They all belong to the same backend, usually a pod
+	Addresses []string
+	// AddressType is one of IPv4, IPv6 or FQDN
+	AddressType string
+	// Hostname of the endpoint, if any
+	Hostname string
+	// TargetRef is the object backing the endpoint, usually a pod
+	TargetRef *v1.ObjectReference
+	// NodeName is the node hosting the endpoint, if known
+	NodeName string
+	// Zone of the endpoint, if known
+	Zone string
+	// Hints are the zones the endpoint should be consumed by with topology aware routing
+	Hints []string
+	// Ports of the endpoint slice
+	Ports []SlicePort
+	// Ready is true if the endpoint can receive traffic. An unknown state is considered ready
+	Ready bool
+	// Serving is true if the endpoint can receive traffic regardless of whether it is terminating.
+	// An unknown state defers to Ready
+	Serving bool
+	// Terminating is true if the endpoint is terminating. An unknown state is considered not terminating
+	Terminating bool
+	// Cluster is the name of the cluster the endpoint was read from, set by the endpointSlices template function
+	Cluster string
+}
+
+// SlicePort is a port of an endpoint slice
+type SlicePort struct {
+	Name     string
+	Protocol v1.Protocol
+	Port     int32
+}
+
+// MergeEndpointSlices flattens the endpoints of all slices of a service.
+// Slices are merged in the order of their names so that renders are stable.
+// An endpoint which shows up in more than one slice while it moves between them is kept once.
+func MergeEndpointSlices(slices []discovery.EndpointSlice) []SliceEndpoint {
+	sort.Slice(slices, func(i, j int) bool {
+		return slices[i].Name < slices[j].Name
+	})
+
+	endpoints := make([]SliceEndpoint, 0)
+	seen := make(map[string]bool)
+	for _, slice := range slices {
+		ports := slicePorts(slice.Ports)
+
+		for _, endpoint := range slice.Endpoints {
+			id := string(slice.AddressType) + "/" + strings.Join(endpoint.Addresses, ",")
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			conditions := endpoint.Conditions
+			ready := conditions.Ready == nil || *conditions.Ready
+			sliceEndpoint := SliceEndpoint{
+				Addresses:   endpoint.Addresses,
+				AddressType: string(slice.AddressType),
+				TargetRef:   endpoint.TargetRef,
+				Ports:       ports,
+				Ready:       ready,
+				Serving:     ready,
+				Terminating: conditions.Terminating != nil && *conditions.Terminating,
+			}
+			if conditions.Serving != nil {
+				sliceEndpoint.Serving = *conditions.Serving
+			}
+			if endpoint.Hostname != nil {
+				sliceEndpoint.Hostname = *endpoint.Hostname
+			}
+			if endpoint.NodeName != nil {
+				sliceEndpoint.NodeName = *endpoint.NodeName
+			}
+			if endpoint.Zone != nil {
+				sliceEndpoint.Zone = *endpoint.Zone
+			}
+			if endpoint.Hints != nil {
+				for _, zone := range endpoint.Hints.ForZones {
+					sliceEndpoint.Hints = append(sliceEndpoint.Hints, zone.Name)
+				}
+			}
+			endpoints = append(endpoints, sliceEndpoint)
+		}
+	}
+	return endpoints
+}
+
+func slicePorts(endpointPorts []discovery.EndpointPort) []SlicePort {
+	ports := make([]SlicePort, 0, len(endpointPorts))
+	for _, endpointPort := range endpointPorts {
+		var port SlicePort
+		if endpointPort.Name != nil {
+			port.Name = *endpointPort.Name
+		}
+		if endpointPort.Protocol != nil {
+			port.Protocol = *endpointPort.Protocol
+		}
+		if endpointPort.Port != nil {
+			port.Port = *endpointPort.Port
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
@@ -0,0 +1,88 @@
+package kubernetes
+
+import (
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestMergeEndpointSlices(t *testing.T) {
+	ready, notReady := true, false
+	http, tcp, port := "http", v1.ProtocolTCP, int32(8080)
+	hostname, nodeName, zone := "web-0", "node-1", "a"
+	slice := func(name string, endpoints ...discovery.Endpoint) discovery.EndpointSlice {
+		return discovery.EndpointSlice{
+			ObjectMeta:  metaV1.ObjectMeta{Name: name},
+			AddressType: discovery.AddressTypeIPv4,
+			Endpoints:   endpoints,
+			Ports:       []discovery.EndpointPort{{Name: &http, Protocol: &tcp, Port: &port}},
+		}
+	}
+
+	t.Run("should merge endpoints of all slices in the order of slice names", func(t *testing.T) {
+		slices := []discovery.EndpointSlice{
+			slice("web-b", discovery.Endpoint{Addresses: []string{"10.0.0.2"}, Conditions: discovery.EndpointConditions{Ready: &notReady}}),
+			slice("web-a", discovery.Endpoint{
+				Addresses:  []string{"10.0.0.1"},
+				Hostname:   &hostname,
+				NodeName:   &nodeName,
+				Zone:       &zone,
+				Hints:      &discovery.EndpointHints{ForZones: []discovery.ForZone{{Name: "a"}, {Name: "b"}}},
+				Conditions: discovery.EndpointConditions{Ready: &ready},
+			}),
+		}
+
+		endpoints := MergeEndpointSlices(slices)
+
+		ports := []SlicePort{{Name: "http", Protocol: v1.ProtocolTCP, Port: 8080}}
+		assert.Equal(t, []SliceEndpoint{
+			{
+				Addresses:   []string{"10.0.0.1"},
+				AddressType: "IPv4",
+				Hostname:    "web-0",
+				NodeName:    "node-1",
+				Zone:        "a",
+				Hints:       []string{"a", "b"},
+				Ports:       ports,
+				Ready:       true,
+				Serving:     true,
+			},
+			{
+				Addresses:   []string{"10.0.0.2"},
+				AddressType: "IPv4",
+				Ports:       ports,
+			},
+		}, endpoints)
+	})
+
+	t.Run("should consider endpoints with unknown readiness ready", func(t *testing.T) {
+		endpoints := MergeEndpointSlices([]discovery.EndpointSlice{slice("web", discovery.Endpoint{Addresses: []string{"10.0.0.1"}})})
+
+		if assert.Len(t, endpoints, 1) {
+			assert.True(t, endpoints[0].Ready)
+		}
+	})
+
+	t.Run("should report serving and terminating endpoints", func(t *testing.T) {
+		endpoints := MergeEndpointSlices([]discovery.EndpointSlice{slice("web", discovery.Endpoint{
+			Addresses:  []string{"10.0.0.1"},
+			Conditions: discovery.EndpointConditions{Ready: &notReady, Serving: &ready, Terminating: &ready},
+		})})
+
+		if assert.Len(t, endpoints, 1) {
+			assert.False(t, endpoints[0].Ready)
+			assert.True(t, endpoints[0].Serving)
+			assert.True(t, endpoints[0].Terminating)
+		}
+	})
+
+	t.Run("should keep endpoints which are in more than one slice once", func(t *testing.T) {
+		endpoint := discovery.Endpoint{Addresses: []string{"10.0.0.1"}}
+
+		endpoints := MergeEndpointSlices([]discovery.EndpointSlice{slice("web-a", endpoint), slice("web-b", endpoint)})
+
+		assert.Len(t, endpoints, 1)
+	})
+}
@@ -0,0 +1,36 @@
+package kubernetes
+
+import (
+	"fmt"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"strings"
+)
+
+// ParseGroupVersionResource parses a resource of the form group/version/resource,
+// like networking.istio.io/v1beta1/virtualservices.
+// Resources of the core group are of the form version/resource, like v1/configmaps.
+func ParseGroupVersionResource(value string) (schema.GroupVersionResource, error) {
+	parts := strings.Split(value, "/")
+	for _, part := range parts {
+		if part == "" {
+			return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q: should be group/version/resource or version/resource", value)
+		}
+	}
+
+	switch len(parts) {
+	case 2:
+		return schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}, nil
+	case 3:
+		return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}, nil
+	default:
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q: should be group/version/resource or version/resource", value)
+	}
+}
+
+// FormatGroupVersionResource formats resource the way ParseGroupVersionResource parses it
+func FormatGroupVersionResource(resource schema.GroupVersionResource) string {
+	if resource.Group == "" {
+		return fmt.Sprintf("%s/%s", resource.Version, resource.Resource)
+	}
+	return fmt.Sprintf("%s/%s/%s", resource.Group, resource.Version, resource.Resource)
+}
@@ -0,0 +1,35 @@
+package kubernetes
+
+import (
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"testing"
+)
+
+func TestParseGroupVersionResource(t *testing.T) {
+	t.Run("should parse group, version and resource", func(t *testing.T) {
+		resource, err := ParseGroupVersionResource("networking.istio.io/v1beta1/virtualservices")
+
+		assert.NoError(t, err)
+		assert.Equal(t, schema.GroupVersionResource{
+			Group:    "networking.istio.io",
+			Version:  "v1beta1",
+			Resource: "virtualservices",
+		}, resource)
+	})
+
+	t.Run("should parse resources of the core group", func(t *testing.T) {
+		resource, err := ParseGroupVersionResource("v1/configmaps")
+
+		assert.NoError(t, err)
+		assert.Equal(t, schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, resource)
+	})
+
+	t.Run("should return error for invalid resources", func(t *testing.T) {
+		for _, value := range []string{"", "configmaps", "a/b/c/d", "/v1/configmaps", "apps//deployments"} {
+			_, err := ParseGroupVersionResource(value)
+
+			assert.EqualError(t, err, `invalid resource "`+value+`": should be group/version/resource or version/resource`)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+// Package logger provides leveled logging for kube-template.
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Level is the severity of a log line.
+type Level int
+
+// Supported log levels in increasing order of severity.
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var levelNames = map[Level]string{
+	DebugLevel: "DEBUG",
+	InfoLevel:  "INFO",
+	WarnLevel:  "WARN",
+	ErrorLevel: "ERROR",
+}
+
+var (
+	lock   = &sync.Mutex{}
+	level  = InfoLevel
+	output = log.New(os.Stderr, "", log.LstdFlags)
+)
+
+// ParseLevel converts a level name like "debug" into a Level.
+func ParseLevel(name string) (Level, error) {
+	for l, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return l, nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("unknown log level \"%s\"", name)
+}
+
+// SetLevel sets the minimum level of lines which are logged.
+func SetLevel(l Level) {
+	lock.Lock()
+	level = l
+	lock.Unlock()
+}
+
+// SetOutput sets the destination of log lines. Defaults to STDERR.
+func SetOutput(w io.Writer) {
+	lock.Lock()
+	output = log.New(w, "", log.LstdFlags)
+	lock.Unlock()
+}
+
+// Debugf logs at debug level
+func Debugf(format string, args ...interface{}) {
+	logf(DebugLevel, format, args...)
+}
+
+// Infof logs at info level
+func Infof(format string, args ...interface{}) {
+	logf(InfoLevel, format, args...)
+}
+
+// Warnf logs at warn level
+func Warnf(format string, args ...interface{}) {
+	logf(WarnLevel, format, args...)
+}
+
+// Errorf logs at error level
+func Errorf(format string, args ...interface{}) {
+	logf(ErrorLevel, format, args...)
+}
+
+func logf(l Level, format string, args ...interface{}) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if l < level {
+		return
+	}
+	output.Printf("[%s] %s", levelNames[l], fmt.Sprintf(format, args...))
+}
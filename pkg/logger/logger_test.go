@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestLogf(t *testing.T) {
+	defer SetOutput(os.Stderr)
+	defer SetLevel(InfoLevel)
+
+	t.Run("should skip lines below the configured level", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		SetOutput(buf)
+		SetLevel(WarnLevel)
+
+		Infof("hidden %d", 1)
+		Warnf("shown %d", 2)
+
+		assert.NotContains(t, buf.String(), "hidden")
+		assert.Contains(t, buf.String(), "[WARN] shown 2")
+	})
+}
+
+func TestParseLevel(t *testing.T) {
+	t.Run("should parse level names ignoring case", func(t *testing.T) {
+		level, err := ParseLevel("debug")
+
+		assert.NoError(t, err)
+		assert.Equal(t, DebugLevel, level)
+	})
+
+	t.Run("should return error for unknown level", func(t *testing.T) {
+		_, err := ParseLevel("verbose")
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,125 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"github.com/thecasualcoder/kube-template/pkg/kubernetes"
+	"sort"
+)
+
+// MultiCluster is implemented by managers which query more than one cluster.
+type MultiCluster interface {
+	// Cluster returns the manager of the cluster with the name
+	Cluster(name string) (Manager, error)
+
+	// Clusters returns the sorted names of the clusters, including the default cluster
+	Clusters() []string
+
+	// DefaultCluster returns the name of the default cluster
+	DefaultCluster() string
+}
+
+// NewMultiCluster creates a manager for the default cluster of client, with the name,
+// which also queries the named clusters of clients through MultiCluster.
+// Events and errors of every cluster are sent on the channels of the returned manager.
+func NewMultiCluster(ctx context.Context, name string, client kubernetes.Client, clients map[string]kubernetes.Client) (Manager, error) {
+	if _, ok := clients[name]; ok {
+		return nil, fmt.Errorf("cluster %q is already the name of the default cluster", name)
+	}
+
+	defaultCluster := New(ctx, client)
+	m := &multiClusterManager{
+		Manager:        defaultCluster,
+		defaultCluster: name,
+		clusters:       map[string]Manager{name: defaultCluster},
+		eventChan:      make(chan struct{}, 1),
+		errChan:        make(chan error, 1),
+	}
+
+	for name, client := range clients {
+		m.clusters[name] = New(ctx, client)
+	}
+	for name, cluster := range m.clusters {
+		go m.forward(ctx, name, cluster)
+	}
+	return m, nil
+}
+
+type multiClusterManager struct {
+	// Manager of the default cluster
+	Manager
+	// defaultCluster is the name of the default cluster
+	defaultCluster string
+	// clusters by name, including the default cluster
+	clusters map[string]Manager
+
+	eventChan chan struct{}
+	errChan   chan error
+}
+
+// forward sends the events and errors of the manager of a cluster on the channels of m
+func (m *multiClusterManager) forward(ctx context.Context, name string, cluster Manager) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cluster.EventChan():
+			select {
+			case m.eventChan <- struct{}{}:
+			default:
+			}
+		case err := <-cluster.ErrorChan():
+			select {
+			case m.errChan <- fmt.Errorf("cluster %s: %w", name, err):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (m *multiClusterManager) Cluster(name string) (Manager, error) {
+	cluster, ok := m.clusters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown cluster %q", name)
+	}
+	return cluster, nil
+}
+
+func (m *multiClusterManager) Clusters() []string {
+	names := make([]string, 0, len(m.clusters))
+	for name := range m.clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *multiClusterManager) DefaultCluster() string {
+	return m.defaultCluster
+}
+
+func (m *multiClusterManager) EventChan() <-chan struct{} {
+	return m.eventChan
+}
+
+func (m *multiClusterManager) ErrorChan() <-chan error {
+	return m.errChan
+}
+
+// PendingKeys returns the keys of every cluster, prefixed with @<cluster>/.
+// Keys are left as they are while the default cluster is the only one.
+func (m *multiClusterManager) PendingKeys() []string {
+	if len(m.clusters) == 1 {
+		return m.Manager.PendingKeys()
+	}
+
+	pending := []string{}
+	for name, cluster := range m.clusters {
+		for _, key := range cluster.PendingKeys() {
+			pending = append(pending, fmt.Sprintf("@%s/%s", name, key))
+		}
+	}
+	sort.Strings(pending)
+	return pending
+}
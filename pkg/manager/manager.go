@@ -1,16 +1,25 @@
 package manager
 
 import (
+	"context"
 	"fmt"
 	"github.com/thecasualcoder/kube-template/pkg/kubernetes"
+	"github.com/thecasualcoder/kube-template/pkg/logger"
+	appsV1 "k8s.io/api/apps/v1"
+	coordinationV1 "k8s.io/api/coordination/v1"
 	v1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	"sync"
-	"time"
 )
 
 // Manager is an interface through which kubernetes objects
 // can be queried as template functions.
+// An empty namespace means the default namespace of the kubernetes client.
 type Manager interface {
 	// Endpoints to list endpoints given namespace and name
 	Endpoints(namespace, name string) (*v1.Endpoints, error)
@@ -18,36 +27,85 @@ type Manager interface {
 	// PodsWithLabels to list pods given namespace and labels
 	PodsWithLabels(namespace string, labels string) (*v1.PodList, error)
 
+	// Service to get a service given namespace and name
+	Service(namespace, name string) (*v1.Service, error)
+
+	// ServicesWithLabels to list services given namespace and labels
+	ServicesWithLabels(namespace string, labels string) (*v1.ServiceList, error)
+
+	// ConfigMap to get a config map given namespace and name
+	ConfigMap(namespace, name string) (*v1.ConfigMap, error)
+
+	// Secret to get a secret given namespace and name
+	Secret(namespace, name string) (*v1.Secret, error)
+
+	// Nodes to list nodes given labels
+	Nodes(labels string) (*v1.NodeList, error)
+
+	// EndpointSlices to list the endpoints of all endpoint slices of a service given namespace and service name
+	EndpointSlices(namespace, serviceName string) ([]kubernetes.SliceEndpoint, error)
+
+	// Deployment to get a deployment given namespace and name
+	Deployment(namespace, name string) (*appsV1.Deployment, error)
+
+	// StatefulSet to get a stateful set given namespace and name
+	StatefulSet(namespace, name string) (*appsV1.StatefulSet, error)
+
+	// DaemonSet to get a daemon set given namespace and name
+	DaemonSet(namespace, name string) (*appsV1.DaemonSet, error)
+
+	// Lease to get a lease given namespace and name
+	Lease(namespace, name string) (*coordinationV1.Lease, error)
+
+	// Resource to get an object of any resource given the resource as group/version/resource, namespace and name
+	Resource(resource, namespace, name string) (*unstructured.Unstructured, error)
+
+	// Resources to list the objects of any resource given the resource as group/version/resource, namespace and labels
+	Resources(resource, namespace, labels string) (*unstructured.UnstructuredList, error)
+
+	// PodsOf to list the pods controlled by a workload given its kind, namespace and name.
+	// Kind is one of deployment, statefulSet or daemonSet.
+	// Pods are selected by the selector of the workload, which is tracked as it changes.
+	PodsOf(kind, namespace, name string) (*v1.PodList, error)
+
+	// Owner to get the top-level controller of an object by walking up its controller owner references.
+	// It is nil if the object has no controller.
+	Owner(object metaV1.Object) (*unstructured.Unstructured, error)
+
 	// EventChan will send events whenever there are changes to resources used by the render function
 	EventChan() <-chan struct{}
 
 	// ErrorChan returns a channel through which errors are propagated during event handling
 	ErrorChan() <-chan error
+
+	// PendingKeys returns the sorted keys of watches which have not received data yet.
+	// Keys are of the form endpoints/<namespace>/<name>
+	PendingKeys() []string
 }
 
-// New to create a new manager for a given kubernetes client
-func New(client kubernetes.Client) Manager {
+// New to create a new manager for a given kubernetes client.
+// All watches are stopped once ctx is done.
+func New(ctx context.Context, client kubernetes.Client) Manager {
 	m := managerImpl{
-		client:       client,
-		eventChan:    make(chan struct{}, 1),
-		throttleChan: make(chan struct{}, 1),
-		errChan:      make(chan error, 1),
-		watchers:     newWatchers(),
-		watcherLock:  &sync.Mutex{},
-		store:        NewStore(),
+		ctx:         ctx,
+		client:      client,
+		eventChan:   make(chan struct{}, 1),
+		errChan:     make(chan error, 1),
+		watchers:    newWatchers(),
+		watcherLock: &sync.Mutex{},
+		store:       NewStore(),
 	}
 
-	go m.throttle()
 	return &m
 }
 
 type managerImpl struct {
+	ctx    context.Context
 	client kubernetes.Client
 
 	// channels
-	eventChan    chan struct{}
-	throttleChan chan struct{}
-	errChan      chan error
+	eventChan chan struct{}
+	errChan   chan error
 
 	// watchers
 	watcherLock *sync.Mutex
@@ -69,47 +127,120 @@ func (m *managerImpl) addWatcher(
 	m.watchers.add(key)
 
 	go func(w watch.Interface) {
-		for event := range w.ResultChan() {
-			if err := eventHandler(event); err != nil {
-				m.errChan <- err
-				w.Stop()
-				break
+		defer w.Stop()
+
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					logger.Debugf("watch of %s was closed, restarting it", key)
+					m.restartWatcher(key)
+					return
+				}
+
+				if event.Type == watch.Error {
+					logger.Warnf("watch of %s failed, restarting it: %v", key, apiErrors.FromObject(event.Object))
+					m.restartWatcher(key)
+					return
+				}
+
+				if err := eventHandler(event); err != nil {
+					select {
+					case m.errChan <- err:
+					case <-m.ctx.Done():
+					}
+					return
+				}
+
+				m.notify()
 			}
-
-			m.throttleChan <- struct{}{}
 		}
 	}(watcher)
 }
 
-func (m *managerImpl) throttle() {
-	timerFired := false
+// restartWatcher forgets the watcher of key so that the next render starts a new one.
+// Data which was already fetched is kept until the new watcher replaces it.
+func (m *managerImpl) restartWatcher(key string) {
+	m.watcherLock.Lock()
+	m.watchers.remove(key)
+	m.watcherLock.Unlock()
+
+	m.notify()
+}
+
+// notify sends an event without blocking.
+// Events are coalesced while one is already pending on eventChan,
+// how long to wait for changes to settle is left to the consumer.
+func (m *managerImpl) notify() {
+	select {
+	case m.eventChan <- struct{}{}:
+	default:
+	}
+}
+
+// watch starts a watcher for the given key unless one is already running.
+// Registration happens under watcherLock so that concurrent renders of
+// several templates share a single watch per resource.
+func (m *managerImpl) watch(
+	key string,
+	startWatcher func() (watch.Interface, error),
+	eventHandler func(watch.Event) error,
+) error {
+	m.watcherLock.Lock()
+	defer m.watcherLock.Unlock()
+
+	if m.watchers.exists(key) {
+		return nil
+	}
+
+	if err := m.ctx.Err(); err != nil {
+		return fmt.Errorf("unable to start watcher for %s: %w", key, err)
+	}
+
+	watcher, err := startWatcher()
+	if err != nil {
+		return fmt.Errorf("unable to start watcher for %s: %w", key, err)
+	}
+
+	m.addWatcher(key, watcher, eventHandler)
+	return nil
+}
 
-	for range m.throttleChan {
-		if !timerFired {
-			timerFired = true
-			time.AfterFunc(2*time.Second, func() {
-				timerFired = false
-				m.eventChan <- struct{}{}
-			})
+// objectHandler handles the events of a watch on a single object.
+// The object is stored under key, and removed from the store once it is deleted.
+func (m *managerImpl) objectHandler(key string) func(watch.Event) error {
+	return func(event watch.Event) error {
+		if event.Type == watch.Deleted {
+			m.store.Delete(key)
+			return nil
 		}
+
+		m.store.Set(key, event.Object)
+		return nil
+	}
+}
+
+// namespaceOrDefault returns the default namespace of the client if namespace is empty
+func (m *managerImpl) namespaceOrDefault(namespace string) string {
+	if namespace == "" {
+		return m.client.Namespace()
 	}
+	return namespace
 }
 
 // Implementation methods go here
 
 func (m *managerImpl) Endpoints(namespace, name string) (*v1.Endpoints, error) {
+	namespace = m.namespaceOrDefault(namespace)
 	key := fmt.Sprintf("endpoints/%s/%s", namespace, name)
 
-	if !m.watchers.exists(key) {
-		watcher, err := m.client.WatchEndpoints(namespace, name)
-		if err != nil {
-			return nil, fmt.Errorf("unable to start watcher for %s: %w", key, err)
-		}
-
-		m.addWatcher(key, watcher, func(event watch.Event) error {
-			m.store.Set(key, event.Object.(*v1.Endpoints))
-			return nil
-		})
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchEndpoints(namespace, name)
+	}, m.objectHandler(key))
+	if err != nil {
+		return nil, err
 	}
 
 	data, present := m.store.Get(key)
@@ -125,23 +256,22 @@ func (m *managerImpl) Endpoints(namespace, name string) (*v1.Endpoints, error) {
 }
 
 func (m *managerImpl) PodsWithLabels(namespace string, labels string) (*v1.PodList, error) {
+	namespace = m.namespaceOrDefault(namespace)
 	key := fmt.Sprintf("podsWithLabels/%s/%s", namespace, labels)
 
-	if !m.watchers.exists(key) {
-		watcher, err := m.client.WatchPodsWithLabels(namespace, labels)
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchPodsWithLabels(namespace, labels)
+	}, func(event watch.Event) error {
+		podList, err := m.client.GetPodsWithLabels(namespace, labels)
 		if err != nil {
-			return nil, fmt.Errorf("unable to start watcher for %s: %w", key, err)
+			return err
 		}
 
-		m.addWatcher(key, watcher, func(event watch.Event) error {
-			podList, err := m.client.GetPodsWithLabels(namespace, labels)
-			if err != nil {
-				return err
-			}
-
-			m.store.Set(key, podList)
-			return nil
-		})
+		m.store.Set(key, podList)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	data, present := m.store.Get(key)
@@ -157,6 +287,423 @@ func (m *managerImpl) PodsWithLabels(namespace string, labels string) (*v1.PodLi
 	return podList, nil
 }
 
+func (m *managerImpl) Service(namespace, name string) (*v1.Service, error) {
+	namespace = m.namespaceOrDefault(namespace)
+	key := fmt.Sprintf("service/%s/%s", namespace, name)
+
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchService(namespace, name)
+	}, m.objectHandler(key))
+	if err != nil {
+		return nil, err
+	}
+
+	data, present := m.store.Get(key)
+	if !present {
+		return nil, ErrDataNotReady
+	}
+
+	service, ok := data.(*v1.Service)
+	if !ok {
+		return nil, fmt.Errorf("fetched service data is corrupt")
+	}
+	return service, nil
+}
+
+func (m *managerImpl) ServicesWithLabels(namespace string, labels string) (*v1.ServiceList, error) {
+	namespace = m.namespaceOrDefault(namespace)
+	key := fmt.Sprintf("servicesWithLabels/%s/%s", namespace, labels)
+
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchServicesWithLabels(namespace, labels)
+	}, func(event watch.Event) error {
+		serviceList, err := m.client.GetServicesWithLabels(namespace, labels)
+		if err != nil {
+			return err
+		}
+
+		m.store.Set(key, serviceList)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, present := m.store.Get(key)
+	if !present {
+		return nil, ErrDataNotReady
+	}
+
+	serviceList, ok := data.(*v1.ServiceList)
+	if !ok {
+		return nil, fmt.Errorf("fetched services list data is corrupt")
+	}
+
+	return serviceList, nil
+}
+
+func (m *managerImpl) ConfigMap(namespace, name string) (*v1.ConfigMap, error) {
+	namespace = m.namespaceOrDefault(namespace)
+	key := fmt.Sprintf("configMap/%s/%s", namespace, name)
+
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchConfigMap(namespace, name)
+	}, m.objectHandler(key))
+	if err != nil {
+		return nil, err
+	}
+
+	data, present := m.store.Get(key)
+	if !present {
+		return nil, ErrDataNotReady
+	}
+
+	configMap, ok := data.(*v1.ConfigMap)
+	if !ok {
+		return nil, fmt.Errorf("fetched config map data is corrupt")
+	}
+	return configMap, nil
+}
+
+func (m *managerImpl) Secret(namespace, name string) (*v1.Secret, error) {
+	namespace = m.namespaceOrDefault(namespace)
+	key := fmt.Sprintf("secret/%s/%s", namespace, name)
+
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchSecret(namespace, name)
+	}, m.objectHandler(key))
+	if err != nil {
+		return nil, err
+	}
+
+	data, present := m.store.Get(key)
+	if !present {
+		return nil, ErrDataNotReady
+	}
+
+	secret, ok := data.(*v1.Secret)
+	if !ok {
+		return nil, fmt.Errorf("fetched secret data is corrupt")
+	}
+	return secret, nil
+}
+
+func (m *managerImpl) Nodes(labels string) (*v1.NodeList, error) {
+	key := fmt.Sprintf("nodes/%s", labels)
+
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchNodesWithLabels(labels)
+	}, func(event watch.Event) error {
+		nodeList, err := m.client.GetNodesWithLabels(labels)
+		if err != nil {
+			return err
+		}
+
+		m.store.Set(key, nodeList)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, present := m.store.Get(key)
+	if !present {
+		return nil, ErrDataNotReady
+	}
+
+	nodeList, ok := data.(*v1.NodeList)
+	if !ok {
+		return nil, fmt.Errorf("fetched nodes list data is corrupt")
+	}
+
+	return nodeList, nil
+}
+
+func (m *managerImpl) EndpointSlices(namespace, serviceName string) ([]kubernetes.SliceEndpoint, error) {
+	namespace = m.namespaceOrDefault(namespace)
+	key := fmt.Sprintf("endpointSlices/%s/%s", namespace, serviceName)
+
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchEndpointSlices(namespace, serviceName)
+	}, func(event watch.Event) error {
+		sliceList, err := m.client.GetEndpointSlices(namespace, serviceName)
+		if err != nil {
+			return err
+		}
+
+		m.store.Set(key, kubernetes.MergeEndpointSlices(sliceList.Items))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, present := m.store.Get(key)
+	if !present {
+		return nil, ErrDataNotReady
+	}
+
+	endpoints, ok := data.([]kubernetes.SliceEndpoint)
+	if !ok {
+		return nil, fmt.Errorf("fetched endpoint slices data is corrupt")
+	}
+
+	return endpoints, nil
+}
+
+func (m *managerImpl) Deployment(namespace, name string) (*appsV1.Deployment, error) {
+	namespace = m.namespaceOrDefault(namespace)
+	key := fmt.Sprintf("deployment/%s/%s", namespace, name)
+
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchDeployment(namespace, name)
+	}, m.objectHandler(key))
+	if err != nil {
+		return nil, err
+	}
+
+	data, present := m.store.Get(key)
+	if !present {
+		return nil, ErrDataNotReady
+	}
+
+	deployment, ok := data.(*appsV1.Deployment)
+	if !ok {
+		return nil, fmt.Errorf("fetched deployment data is corrupt")
+	}
+	return deployment, nil
+}
+
+func (m *managerImpl) StatefulSet(namespace, name string) (*appsV1.StatefulSet, error) {
+	namespace = m.namespaceOrDefault(namespace)
+	key := fmt.Sprintf("statefulSet/%s/%s", namespace, name)
+
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchStatefulSet(namespace, name)
+	}, m.objectHandler(key))
+	if err != nil {
+		return nil, err
+	}
+
+	data, present := m.store.Get(key)
+	if !present {
+		return nil, ErrDataNotReady
+	}
+
+	statefulSet, ok := data.(*appsV1.StatefulSet)
+	if !ok {
+		return nil, fmt.Errorf("fetched stateful set data is corrupt")
+	}
+	return statefulSet, nil
+}
+
+func (m *managerImpl) DaemonSet(namespace, name string) (*appsV1.DaemonSet, error) {
+	namespace = m.namespaceOrDefault(namespace)
+	key := fmt.Sprintf("daemonSet/%s/%s", namespace, name)
+
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchDaemonSet(namespace, name)
+	}, m.objectHandler(key))
+	if err != nil {
+		return nil, err
+	}
+
+	data, present := m.store.Get(key)
+	if !present {
+		return nil, ErrDataNotReady
+	}
+
+	daemonSet, ok := data.(*appsV1.DaemonSet)
+	if !ok {
+		return nil, fmt.Errorf("fetched daemon set data is corrupt")
+	}
+	return daemonSet, nil
+}
+
+func (m *managerImpl) Lease(namespace, name string) (*coordinationV1.Lease, error) {
+	namespace = m.namespaceOrDefault(namespace)
+	key := fmt.Sprintf("lease/%s/%s", namespace, name)
+
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchLease(namespace, name)
+	}, m.objectHandler(key))
+	if err != nil {
+		return nil, err
+	}
+
+	data, present := m.store.Get(key)
+	if !present {
+		return nil, ErrDataNotReady
+	}
+
+	lease, ok := data.(*coordinationV1.Lease)
+	if !ok {
+		return nil, fmt.Errorf("fetched lease data is corrupt")
+	}
+	return lease, nil
+}
+
+func (m *managerImpl) Resource(resource, namespace, name string) (*unstructured.Unstructured, error) {
+	gvr, err := kubernetes.ParseGroupVersionResource(resource)
+	if err != nil {
+		return nil, err
+	}
+	return m.resource(gvr, namespace, name)
+}
+
+func (m *managerImpl) resource(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	resource := kubernetes.FormatGroupVersionResource(gvr)
+	namespace = m.namespaceOrDefault(namespace)
+	key := fmt.Sprintf("resource/%s/%s/%s", resource, namespace, name)
+
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchResource(gvr, namespace, name)
+	}, m.objectHandler(key))
+	if err != nil {
+		return nil, err
+	}
+
+	data, present := m.store.Get(key)
+	if !present {
+		return nil, ErrDataNotReady
+	}
+
+	object, ok := data.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("fetched %s data is corrupt", resource)
+	}
+	return object, nil
+}
+
+func (m *managerImpl) Resources(resource, namespace, labels string) (*unstructured.UnstructuredList, error) {
+	gvr, err := kubernetes.ParseGroupVersionResource(resource)
+	if err != nil {
+		return nil, err
+	}
+	return m.resources(gvr, namespace, labels)
+}
+
+func (m *managerImpl) resources(gvr schema.GroupVersionResource, namespace, labels string) (*unstructured.UnstructuredList, error) {
+	resource := kubernetes.FormatGroupVersionResource(gvr)
+	namespace = m.namespaceOrDefault(namespace)
+	key := fmt.Sprintf("resources/%s/%s/%s", resource, namespace, labels)
+
+	err := m.watch(key, func() (watch.Interface, error) {
+		return m.client.WatchResourcesWithLabels(gvr, namespace, labels)
+	}, func(event watch.Event) error {
+		list, err := m.client.GetResourcesWithLabels(gvr, namespace, labels)
+		if err != nil {
+			return err
+		}
+
+		m.store.Set(key, list)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, present := m.store.Get(key)
+	if !present {
+		return nil, ErrDataNotReady
+	}
+
+	list, ok := data.(*unstructured.UnstructuredList)
+	if !ok {
+		return nil, fmt.Errorf("fetched %s list data is corrupt", resource)
+	}
+
+	return list, nil
+}
+
+var replicaSets = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+
+func (m *managerImpl) PodsOf(kind, namespace, name string) (*v1.PodList, error) {
+	var workload metaV1.Object
+	var selector *metaV1.LabelSelector
+	switch kind {
+	case "deployment":
+		deployment, err := m.Deployment(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		workload, selector = deployment, deployment.Spec.Selector
+	case "statefulSet":
+		statefulSet, err := m.StatefulSet(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		workload, selector = statefulSet, statefulSet.Spec.Selector
+	case "daemonSet":
+		daemonSet, err := m.DaemonSet(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		workload, selector = daemonSet, daemonSet.Spec.Selector
+	default:
+		return nil, fmt.Errorf("unsupported kind %q: should be deployment, statefulSet or daemonSet", kind)
+	}
+
+	labelSelector, err := metaV1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector of %s %s/%s: %w", kind, workload.GetNamespace(), name, err)
+	}
+	labels := labelSelector.String()
+
+	pods, err := m.PodsWithLabels(namespace, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	// selectors may overlap, so only pods controlled by the workload are kept.
+	// Pods of a deployment are controlled by its replica sets, which share its selector.
+	controllers := map[types.UID]bool{workload.GetUID(): true}
+	if kind == "deployment" {
+		replicaSetList, err := m.resources(replicaSets, namespace, labels)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range replicaSetList.Items {
+			replicaSet := &replicaSetList.Items[i]
+			if controller := metaV1.GetControllerOf(replicaSet); controller != nil && controller.UID == workload.GetUID() {
+				controllers[replicaSet.GetUID()] = true
+			}
+		}
+	}
+
+	podList := &v1.PodList{}
+	for i := range pods.Items {
+		if controller := metaV1.GetControllerOf(&pods.Items[i]); controller != nil && controllers[controller.UID] {
+			podList.Items = append(podList.Items, pods.Items[i])
+		}
+	}
+	return podList, nil
+}
+
+func (m *managerImpl) Owner(object metaV1.Object) (*unstructured.Unstructured, error) {
+	var owner *unstructured.Unstructured
+	walked := map[types.UID]bool{object.GetUID(): true}
+	for controller := metaV1.GetControllerOf(object); controller != nil; controller = metaV1.GetControllerOf(owner) {
+		if walked[controller.UID] {
+			return nil, fmt.Errorf("owner references of %s/%s form a cycle", object.GetNamespace(), object.GetName())
+		}
+		walked[controller.UID] = true
+
+		resource, err := m.client.ResourceFor(controller.APIVersion, controller.Kind)
+		if err != nil {
+			return nil, err
+		}
+
+		// owner references are always in the namespace of the object, or cluster scoped
+		owner, err = m.resource(resource, object.GetNamespace(), controller.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return owner, nil
+}
+
 func (m *managerImpl) EventChan() <-chan struct{} {
 	return m.eventChan
 }
@@ -164,3 +711,13 @@ func (m *managerImpl) EventChan() <-chan struct{} {
 func (m *managerImpl) ErrorChan() <-chan error {
 	return m.errChan
 }
+
+func (m *managerImpl) PendingKeys() []string {
+	pending := []string{}
+	for _, key := range m.watchers.keys() {
+		if _, present := m.store.Get(key); !present {
+			pending = append(pending, key)
+		}
+	}
+	return pending
+}
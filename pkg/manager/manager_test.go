@@ -1,11 +1,21 @@
 package manager
 
 import (
+	"context"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/thecasualcoder/kube-template/mock"
+	"github.com/thecasualcoder/kube-template/pkg/kubernetes"
+	appsV1 "k8s.io/api/apps/v1"
+	coordinationV1 "k8s.io/api/coordination/v1"
 	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
+	"sync"
 	"testing"
 	"time"
 )
@@ -17,7 +27,7 @@ func TestNew(t *testing.T) {
 
 		client := mock.NewMockClient(ctrl)
 
-		mgr := New(client)
+		mgr := New(context.Background(), client)
 
 		assert.NotNil(t, mgr)
 	})
@@ -27,7 +37,7 @@ func TestManager_Endpoints(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	client := mock.NewMockClient(ctrl)
-	mgr := New(client)
+	mgr := New(context.Background(), client)
 	expectedEndpoints := v1.Endpoints{
 		Subsets: []v1.EndpointSubset{
 			{
@@ -65,11 +75,147 @@ func TestManager_Endpoints(t *testing.T) {
 	assert.Equal(t, expectedEndpoints, actualEndpoints)
 }
 
+func TestManager_SharesWatchers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	mgr := New(context.Background(), client)
+	namespace := "default"
+	resourceName := "nginx"
+	watcher, _ := safeWatcher(ctrl)
+	client.EXPECT().WatchEndpoints(namespace, resourceName).Return(watcher, nil).Times(1)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := mgr.Endpoints(namespace, resourceName)
+			assert.Equal(t, ErrDataNotReady, err)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestManager_EventChan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	mgr := New(context.Background(), client)
+	watcher, resultChan := safeWatcher(ctrl)
+	client.EXPECT().WatchEndpoints("default", "nginx").Return(watcher, nil)
+
+	_, _ = mgr.Endpoints("default", "nginx")
+	for i := 0; i < 3; i++ {
+		resultChan <- watch.Event{Object: &v1.Endpoints{}}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Len(t, mgr.EventChan(), 1, "events should be coalesced while one is pending")
+}
+
+func TestManager_StopsWatchersWhenContextIsDone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	mgr := New(ctx, client)
+	watcher := mock.NewMockInterface(ctrl)
+	watcher.EXPECT().ResultChan().Return(make(chan watch.Event)).AnyTimes()
+	stopped := make(chan struct{})
+	watcher.EXPECT().Stop().Do(func() { close(stopped) })
+	client.EXPECT().WatchEndpoints("default", "nginx").Return(watcher, nil)
+
+	_, _ = mgr.Endpoints("default", "nginx")
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("watcher was not stopped")
+	}
+
+	_, err := mgr.PodsWithLabels("default", "app=nginx")
+	assert.Error(t, err, "no new watchers should be started")
+}
+
+func TestManager_WatchEvents(t *testing.T) {
+	t.Run("should remove deleted objects", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		mgr := New(context.Background(), client)
+		watcher, resultChan := safeWatcher(ctrl)
+		resultChan <- watch.Event{Type: watch.Added, Object: &v1.Service{}}
+		client.EXPECT().WatchService("default", "nginx").Return(watcher, nil)
+
+		_, _ = mgr.Service("default", "nginx")
+		<-mgr.EventChan()
+		_, err := mgr.Service("default", "nginx")
+		assert.NoError(t, err)
+
+		resultChan <- watch.Event{Type: watch.Deleted, Object: &v1.Service{}}
+		<-mgr.EventChan()
+		_, err = mgr.Service("default", "nginx")
+		assert.Equal(t, ErrDataNotReady, err)
+	})
+
+	t.Run("should restart the watcher after an error event", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		mgr := New(context.Background(), client)
+		failedWatcher, failedChan := safeWatcher(ctrl)
+		failedChan <- watch.Event{Type: watch.Error, Object: &metaV1.Status{Message: "too old resource version"}}
+		watcher, resultChan := safeWatcher(ctrl)
+		resultChan <- watch.Event{Type: watch.Added, Object: &v1.ConfigMap{Data: map[string]string{"a": "b"}}}
+		gomock.InOrder(
+			client.EXPECT().WatchConfigMap("default", "config").Return(failedWatcher, nil),
+			client.EXPECT().WatchConfigMap("default", "config").Return(watcher, nil),
+		)
+
+		_, err := mgr.ConfigMap("default", "config")
+		assert.Equal(t, ErrDataNotReady, err)
+		<-mgr.EventChan()
+		assert.Len(t, mgr.ErrorChan(), 0, "error events should not stop kube-template")
+
+		var configMap *v1.ConfigMap
+		for i := 1; i <= 3; i++ {
+			configMap, err = mgr.ConfigMap("default", "config")
+			if err == ErrDataNotReady {
+				time.Sleep(time.Duration(i*100) * time.Millisecond)
+				continue
+			}
+			break
+		}
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, map[string]string{"a": "b"}, configMap.Data)
+		}
+	})
+}
+
+func TestManager_PendingKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	mgr := New(context.Background(), client)
+	endpointsWatcher, _ := safeWatcher(ctrl)
+	podsWatcher, _ := safeWatcher(ctrl)
+	client.EXPECT().WatchEndpoints("default", "nginx").Return(endpointsWatcher, nil)
+	client.EXPECT().WatchPodsWithLabels("default", "app=nginx").Return(podsWatcher, nil)
+
+	_, _ = mgr.PodsWithLabels("default", "app=nginx")
+	_, _ = mgr.Endpoints("default", "nginx")
+
+	assert.Equal(t, []string{"endpoints/default/nginx", "podsWithLabels/default/app=nginx"}, mgr.PendingKeys())
+}
+
 func TestManager_PodsWithLabels(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	client := mock.NewMockClient(ctrl)
-	mgr := New(client)
+	mgr := New(context.Background(), client)
 	expectedPodList := v1.PodList{
 		Items: []v1.Pod{
 			{
@@ -100,9 +246,644 @@ func TestManager_PodsWithLabels(t *testing.T) {
 	assert.Equal(t, expectedPodList, actualPodList)
 }
 
+func TestManager_Service(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	mgr := New(context.Background(), client)
+	expectedService := v1.Service{
+		Spec: v1.ServiceSpec{
+			ClusterIP: "10.96.0.10",
+			Ports: []v1.ServicePort{
+				{Name: "metrics", Port: 9100},
+			},
+		},
+	}
+	namespace := "default"
+	resourceName := "nginx"
+	watcher, resultChan := safeWatcher(ctrl)
+	resultChan <- watch.Event{
+		Object: &expectedService,
+	}
+	client.EXPECT().WatchService(namespace, resourceName).Return(watcher, nil)
+
+	var actualService v1.Service
+	for i := 1; i <= 3; i++ {
+		service, err := mgr.Service(namespace, resourceName)
+		if err == ErrDataNotReady {
+			time.Sleep(time.Duration(i*100) * time.Millisecond)
+			continue
+		}
+
+		if assert.NoError(t, err) {
+			actualService = *service
+		}
+	}
+
+	assert.Equal(t, expectedService, actualService)
+}
+
+func TestManager_ServicesWithLabels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	mgr := New(context.Background(), client)
+	expectedServiceList := v1.ServiceList{
+		Items: []v1.Service{
+			{
+				Spec: v1.ServiceSpec{ClusterIP: "10.96.0.10"},
+			},
+		},
+	}
+	namespace := "default"
+	labelSelector := "app=nginx"
+	client.EXPECT().GetServicesWithLabels(namespace, labelSelector).Return(&expectedServiceList, nil)
+	watcher, eventChan := safeWatcher(ctrl)
+	eventChan <- watch.Event{}
+	client.EXPECT().WatchServicesWithLabels(namespace, labelSelector).Return(watcher, nil)
+
+	var actualServiceList v1.ServiceList
+	for i := 1; i <= 3; i++ {
+		serviceList, err := mgr.ServicesWithLabels(namespace, labelSelector)
+		if err == ErrDataNotReady {
+			time.Sleep(time.Duration(i*100) * time.Millisecond)
+			continue
+		}
+
+		if assert.NoError(t, err) {
+			actualServiceList = *serviceList
+		}
+	}
+
+	assert.Equal(t, expectedServiceList, actualServiceList)
+}
+
+func TestManager_ConfigMap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	mgr := New(context.Background(), client)
+	expectedConfigMap := v1.ConfigMap{
+		Data: map[string]string{"feature": "enabled"},
+	}
+	watcher, resultChan := safeWatcher(ctrl)
+	resultChan <- watch.Event{
+		Object: &expectedConfigMap,
+	}
+	client.EXPECT().WatchConfigMap("default", "flags").Return(watcher, nil)
+
+	var actualConfigMap v1.ConfigMap
+	for i := 1; i <= 3; i++ {
+		configMap, err := mgr.ConfigMap("default", "flags")
+		if err == ErrDataNotReady {
+			time.Sleep(time.Duration(i*100) * time.Millisecond)
+			continue
+		}
+
+		if assert.NoError(t, err) {
+			actualConfigMap = *configMap
+		}
+	}
+
+	assert.Equal(t, expectedConfigMap, actualConfigMap)
+}
+
+func TestManager_Secret(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	mgr := New(context.Background(), client)
+	expectedSecret := v1.Secret{
+		Data: map[string][]byte{"password": []byte("hunter2")},
+	}
+	watcher, resultChan := safeWatcher(ctrl)
+	resultChan <- watch.Event{
+		Object: &expectedSecret,
+	}
+	client.EXPECT().WatchSecret("default", "basic-auth").Return(watcher, nil)
+
+	var actualSecret v1.Secret
+	for i := 1; i <= 3; i++ {
+		secret, err := mgr.Secret("default", "basic-auth")
+		if err == ErrDataNotReady {
+			time.Sleep(time.Duration(i*100) * time.Millisecond)
+			continue
+		}
+
+		if assert.NoError(t, err) {
+			actualSecret = *secret
+		}
+	}
+
+	assert.Equal(t, expectedSecret, actualSecret)
+}
+
+func TestManager_Nodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	mgr := New(context.Background(), client)
+	expectedNodeList := v1.NodeList{
+		Items: []v1.Node{
+			{
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "192.168.0.10"}},
+				},
+			},
+		},
+	}
+	labelSelector := "node-role.kubernetes.io/proxy"
+	client.EXPECT().GetNodesWithLabels(labelSelector).Return(&expectedNodeList, nil)
+	watcher, eventChan := safeWatcher(ctrl)
+	eventChan <- watch.Event{}
+	client.EXPECT().WatchNodesWithLabels(labelSelector).Return(watcher, nil)
+
+	var actualNodeList v1.NodeList
+	for i := 1; i <= 3; i++ {
+		nodeList, err := mgr.Nodes(labelSelector)
+		if err == ErrDataNotReady {
+			time.Sleep(time.Duration(i*100) * time.Millisecond)
+			continue
+		}
+
+		if assert.NoError(t, err) {
+			actualNodeList = *nodeList
+		}
+	}
+
+	assert.Equal(t, expectedNodeList, actualNodeList)
+}
+
+func TestManager_EndpointSlices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	mgr := New(context.Background(), client)
+	sliceList := discovery.EndpointSliceList{
+		Items: []discovery.EndpointSlice{
+			{
+				AddressType: discovery.AddressTypeIPv4,
+				Endpoints:   []discovery.Endpoint{{Addresses: []string{"10.0.0.1"}}},
+			},
+		},
+	}
+	client.EXPECT().GetEndpointSlices("default", "nginx").Return(&sliceList, nil)
+	watcher, eventChan := safeWatcher(ctrl)
+	eventChan <- watch.Event{}
+	client.EXPECT().WatchEndpointSlices("default", "nginx").Return(watcher, nil)
+
+	var actualEndpoints []kubernetes.SliceEndpoint
+	for i := 1; i <= 3; i++ {
+		endpoints, err := mgr.EndpointSlices("default", "nginx")
+		if err == ErrDataNotReady {
+			time.Sleep(time.Duration(i*100) * time.Millisecond)
+			continue
+		}
+
+		if assert.NoError(t, err) {
+			actualEndpoints = endpoints
+		}
+	}
+
+	assert.Equal(t, kubernetes.MergeEndpointSlices(sliceList.Items), actualEndpoints)
+}
+
+func TestManager_Workloads(t *testing.T) {
+	replicas := int32(3)
+
+	t.Run("should get deployment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		mgr := New(context.Background(), client)
+		expectedDeployment := appsV1.Deployment{Spec: appsV1.DeploymentSpec{Replicas: &replicas}}
+		watcher, resultChan := safeWatcher(ctrl)
+		resultChan <- watch.Event{Object: &expectedDeployment}
+		client.EXPECT().WatchDeployment("default", "web").Return(watcher, nil)
+
+		var actualDeployment appsV1.Deployment
+		for i := 1; i <= 3; i++ {
+			deployment, err := mgr.Deployment("default", "web")
+			if err == ErrDataNotReady {
+				time.Sleep(time.Duration(i*100) * time.Millisecond)
+				continue
+			}
+
+			if assert.NoError(t, err) {
+				actualDeployment = *deployment
+			}
+		}
+
+		assert.Equal(t, expectedDeployment, actualDeployment)
+	})
+
+	t.Run("should get stateful set", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		mgr := New(context.Background(), client)
+		expectedStatefulSet := appsV1.StatefulSet{Spec: appsV1.StatefulSetSpec{ServiceName: "redis", Replicas: &replicas}}
+		watcher, resultChan := safeWatcher(ctrl)
+		resultChan <- watch.Event{Object: &expectedStatefulSet}
+		client.EXPECT().WatchStatefulSet("default", "redis").Return(watcher, nil)
+
+		var actualStatefulSet appsV1.StatefulSet
+		for i := 1; i <= 3; i++ {
+			statefulSet, err := mgr.StatefulSet("default", "redis")
+			if err == ErrDataNotReady {
+				time.Sleep(time.Duration(i*100) * time.Millisecond)
+				continue
+			}
+
+			if assert.NoError(t, err) {
+				actualStatefulSet = *statefulSet
+			}
+		}
+
+		assert.Equal(t, expectedStatefulSet, actualStatefulSet)
+	})
+
+	t.Run("should get daemon set", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		mgr := New(context.Background(), client)
+		expectedDaemonSet := appsV1.DaemonSet{Status: appsV1.DaemonSetStatus{NumberReady: 2}}
+		watcher, resultChan := safeWatcher(ctrl)
+		resultChan <- watch.Event{Object: &expectedDaemonSet}
+		client.EXPECT().WatchDaemonSet("kube-system", "proxy").Return(watcher, nil)
+
+		var actualDaemonSet appsV1.DaemonSet
+		for i := 1; i <= 3; i++ {
+			daemonSet, err := mgr.DaemonSet("kube-system", "proxy")
+			if err == ErrDataNotReady {
+				time.Sleep(time.Duration(i*100) * time.Millisecond)
+				continue
+			}
+
+			if assert.NoError(t, err) {
+				actualDaemonSet = *daemonSet
+			}
+		}
+
+		assert.Equal(t, expectedDaemonSet, actualDaemonSet)
+	})
+}
+
+func TestManager_Lease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	mgr := New(context.Background(), client)
+	holder := "db-0"
+	expectedLease := coordinationV1.Lease{Spec: coordinationV1.LeaseSpec{HolderIdentity: &holder}}
+	watcher, resultChan := safeWatcher(ctrl)
+	resultChan <- watch.Event{Object: &expectedLease}
+	client.EXPECT().WatchLease("default", "db-leader").Return(watcher, nil)
+
+	var actualLease coordinationV1.Lease
+	for i := 1; i <= 3; i++ {
+		lease, err := mgr.Lease("default", "db-leader")
+		if err == ErrDataNotReady {
+			time.Sleep(time.Duration(i*100) * time.Millisecond)
+			continue
+		}
+
+		if assert.NoError(t, err) {
+			actualLease = *lease
+		}
+	}
+
+	assert.Equal(t, expectedLease, actualLease)
+}
+
+func TestManager_Resource(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1beta1", Resource: "virtualservices"}
+
+	t.Run("should get object of resource", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		mgr := New(context.Background(), client)
+		expectedObject := unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"spec": map[string]interface{}{"hosts": []interface{}{"reviews"}},
+			},
+		}
+		watcher, resultChan := safeWatcher(ctrl)
+		resultChan <- watch.Event{
+			Object: &expectedObject,
+		}
+		client.EXPECT().WatchResource(gvr, "default", "reviews").Return(watcher, nil)
+
+		var actualObject unstructured.Unstructured
+		for i := 1; i <= 3; i++ {
+			object, err := mgr.Resource("networking.istio.io/v1beta1/virtualservices", "default", "reviews")
+			if err == ErrDataNotReady {
+				time.Sleep(time.Duration(i*100) * time.Millisecond)
+				continue
+			}
+
+			if assert.NoError(t, err) {
+				actualObject = *object
+			}
+		}
+
+		assert.Equal(t, expectedObject, actualObject)
+	})
+
+	t.Run("should return error for invalid resource", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		mgr := New(context.Background(), client)
+
+		_, err := mgr.Resource("virtualservices", "default", "reviews")
+
+		assert.EqualError(t, err, `invalid resource "virtualservices": should be group/version/resource or version/resource`)
+	})
+}
+
+func TestManager_Resources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	mgr := New(context.Background(), client)
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "routes"}
+	expectedList := unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{
+			{Object: map[string]interface{}{"spec": map[string]interface{}{"host": "example.com"}}},
+		},
+	}
+	labelSelector := "app=web"
+	client.EXPECT().GetResourcesWithLabels(gvr, "default", labelSelector).Return(&expectedList, nil)
+	watcher, eventChan := safeWatcher(ctrl)
+	eventChan <- watch.Event{}
+	client.EXPECT().WatchResourcesWithLabels(gvr, "default", labelSelector).Return(watcher, nil)
+
+	var actualList unstructured.UnstructuredList
+	for i := 1; i <= 3; i++ {
+		list, err := mgr.Resources("example.com/v1/routes", "default", labelSelector)
+		if err == ErrDataNotReady {
+			time.Sleep(time.Duration(i*100) * time.Millisecond)
+			continue
+		}
+
+		if assert.NoError(t, err) {
+			actualList = *list
+		}
+	}
+
+	assert.Equal(t, expectedList, actualList)
+}
+
+func TestManager_PodsOf(t *testing.T) {
+	controlledBy := func(kind, name, uid string) []metaV1.OwnerReference {
+		controller := true
+		return []metaV1.OwnerReference{{Kind: kind, Name: name, UID: types.UID(uid), Controller: &controller}}
+	}
+
+	t.Run("should list pods of the replica sets of a deployment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		mgr := New(context.Background(), client)
+		deployment := appsV1.Deployment{
+			ObjectMeta: metaV1.ObjectMeta{Name: "web", UID: "deployment"},
+			Spec:       appsV1.DeploymentSpec{Selector: &metaV1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+		}
+		deploymentWatcher, deploymentChan := safeWatcher(ctrl)
+		deploymentChan <- watch.Event{Object: &deployment}
+		client.EXPECT().WatchDeployment("default", "web").Return(deploymentWatcher, nil)
+
+		ownPod := v1.Pod{ObjectMeta: metaV1.ObjectMeta{Name: "web-1", OwnerReferences: controlledBy("ReplicaSet", "web-1", "replicaSet")}}
+		otherPod := v1.Pod{ObjectMeta: metaV1.ObjectMeta{Name: "other-1", OwnerReferences: controlledBy("ReplicaSet", "other-1", "other")}}
+		client.EXPECT().GetPodsWithLabels("default", "app=web").Return(&v1.PodList{Items: []v1.Pod{ownPod, otherPod}}, nil).AnyTimes()
+		podWatcher, podChan := safeWatcher(ctrl)
+		podChan <- watch.Event{}
+		client.EXPECT().WatchPodsWithLabels("default", "app=web").Return(podWatcher, nil)
+
+		replicaSet := unstructured.Unstructured{}
+		replicaSet.SetUID("replicaSet")
+		replicaSet.SetOwnerReferences(controlledBy("Deployment", "web", "deployment"))
+		gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+		client.EXPECT().GetResourcesWithLabels(gvr, "default", "app=web").
+			Return(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{replicaSet}}, nil).AnyTimes()
+		replicaSetWatcher, replicaSetChan := safeWatcher(ctrl)
+		replicaSetChan <- watch.Event{}
+		client.EXPECT().WatchResourcesWithLabels(gvr, "default", "app=web").Return(replicaSetWatcher, nil)
+
+		var actualPodList v1.PodList
+		for i := 1; i <= 5; i++ {
+			podList, err := mgr.PodsOf("deployment", "default", "web")
+			if err == ErrDataNotReady {
+				time.Sleep(time.Duration(i*100) * time.Millisecond)
+				continue
+			}
+
+			if assert.NoError(t, err) {
+				actualPodList = *podList
+			}
+		}
+
+		assert.Equal(t, []v1.Pod{ownPod}, actualPodList.Items)
+	})
+
+	t.Run("should return error for unsupported kinds", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		mgr := New(context.Background(), client)
+
+		_, err := mgr.PodsOf("job", "default", "web")
+
+		assert.EqualError(t, err, `unsupported kind "job": should be deployment, statefulSet or daemonSet`)
+	})
+}
+
+func TestManager_Owner(t *testing.T) {
+	controller := true
+	replicaSets := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	t.Run("should walk up to the top-level controller", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		mgr := New(context.Background(), client)
+		pod := &v1.Pod{ObjectMeta: metaV1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "web-1-abc",
+			OwnerReferences: []metaV1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-1", UID: "replicaSet", Controller: &controller}},
+		}}
+		replicaSet := &unstructured.Unstructured{}
+		replicaSet.SetOwnerReferences([]metaV1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "deployment", Controller: &controller}})
+		deployment := &unstructured.Unstructured{}
+		deployment.SetName("web")
+
+		client.EXPECT().ResourceFor("apps/v1", "ReplicaSet").Return(replicaSets, nil).AnyTimes()
+		client.EXPECT().ResourceFor("apps/v1", "Deployment").Return(deployments, nil).AnyTimes()
+		replicaSetWatcher, replicaSetChan := safeWatcher(ctrl)
+		replicaSetChan <- watch.Event{Object: replicaSet}
+		client.EXPECT().WatchResource(replicaSets, "default", "web-1").Return(replicaSetWatcher, nil)
+		deploymentWatcher, deploymentChan := safeWatcher(ctrl)
+		deploymentChan <- watch.Event{Object: deployment}
+		client.EXPECT().WatchResource(deployments, "default", "web").Return(deploymentWatcher, nil)
+
+		var actualOwner *unstructured.Unstructured
+		for i := 1; i <= 5; i++ {
+			owner, err := mgr.Owner(pod)
+			if err == ErrDataNotReady {
+				time.Sleep(time.Duration(i*100) * time.Millisecond)
+				continue
+			}
+
+			if assert.NoError(t, err) {
+				actualOwner = owner
+			}
+		}
+
+		assert.Equal(t, deployment, actualOwner)
+	})
+
+	t.Run("should return nil for objects without controller", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		mgr := New(context.Background(), client)
+
+		owner, err := mgr.Owner(&v1.Pod{})
+
+		assert.NoError(t, err)
+		assert.Nil(t, owner)
+	})
+}
+
+func TestMultiCluster(t *testing.T) {
+	t.Run("should query every cluster", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		euWestClient := mock.NewMockClient(ctrl)
+		mgr, err := NewMultiCluster(context.Background(), "us-east", client, map[string]kubernetes.Client{"eu-west": euWestClient})
+		if !assert.NoError(t, err) {
+			return
+		}
+		podList := v1.PodList{Items: []v1.Pod{{ObjectMeta: metaV1.ObjectMeta{Name: "api-0"}}}}
+		euWestClient.EXPECT().GetPodsWithLabels("default", "app=api").Return(&podList, nil)
+		watcher, eventChan := safeWatcher(ctrl)
+		eventChan <- watch.Event{}
+		euWestClient.EXPECT().WatchPodsWithLabels("default", "app=api").Return(watcher, nil)
+		serviceWatcher, serviceChan := safeWatcher(ctrl)
+		service := v1.Service{ObjectMeta: metaV1.ObjectMeta{Name: "api"}}
+		serviceChan <- watch.Event{Type: watch.Added, Object: &service}
+		client.EXPECT().WatchService("default", "api").Return(serviceWatcher, nil)
+
+		euWest, err := mgr.(MultiCluster).Cluster("eu-west")
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = euWest.PodsWithLabels("default", "app=api")
+		assert.Equal(t, ErrDataNotReady, err)
+		usEast, err := mgr.(MultiCluster).Cluster("us-east")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, mgr.(*multiClusterManager).Manager, usEast, "the default cluster should be addressable by its name")
+		_, err = mgr.Service("default", "api")
+		assert.Equal(t, ErrDataNotReady, err)
+
+		var actualPodList *v1.PodList
+		var actualService *v1.Service
+		for i := 1; i <= 3; i++ {
+			<-mgr.EventChan()
+			actualPodList, _ = euWest.PodsWithLabels("default", "app=api")
+			actualService, _ = mgr.Service("default", "api")
+			if actualPodList != nil && actualService != nil {
+				break
+			}
+		}
+
+		assert.Equal(t, &podList, actualPodList)
+		assert.Equal(t, &service, actualService)
+		assert.Equal(t, []string{"eu-west", "us-east"}, mgr.(MultiCluster).Clusters())
+		assert.Equal(t, "us-east", mgr.(MultiCluster).DefaultCluster())
+	})
+
+	t.Run("should prefix pending keys with their cluster", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		euWestClient := mock.NewMockClient(ctrl)
+		mgr, _ := NewMultiCluster(context.Background(), "us-east", client, map[string]kubernetes.Client{"eu-west": euWestClient})
+		watcher, _ := safeWatcher(ctrl)
+		client.EXPECT().WatchEndpoints("default", "api").Return(watcher, nil)
+		euWestWatcher, _ := safeWatcher(ctrl)
+		euWestClient.EXPECT().WatchEndpoints("default", "api").Return(euWestWatcher, nil)
+
+		_, _ = mgr.Endpoints("default", "api")
+		euWest, _ := mgr.(MultiCluster).Cluster("eu-west")
+		_, _ = euWest.Endpoints("default", "api")
+
+		assert.Equal(t, []string{"@eu-west/endpoints/default/api", "@us-east/endpoints/default/api"}, mgr.PendingKeys())
+	})
+
+	t.Run("should not prefix pending keys of the default cluster alone", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client := mock.NewMockClient(ctrl)
+		mgr, _ := NewMultiCluster(context.Background(), "us-east", client, map[string]kubernetes.Client{})
+		watcher, _ := safeWatcher(ctrl)
+		client.EXPECT().WatchEndpoints("default", "api").Return(watcher, nil)
+
+		_, _ = mgr.Endpoints("default", "api")
+
+		assert.Equal(t, []string{"endpoints/default/api"}, mgr.PendingKeys())
+	})
+
+	t.Run("should return error for unknown clusters", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mgr, _ := NewMultiCluster(context.Background(), "us-east", mock.NewMockClient(ctrl), map[string]kubernetes.Client{})
+
+		_, err := mgr.(MultiCluster).Cluster("eu-west")
+
+		assert.EqualError(t, err, `unknown cluster "eu-west"`)
+	})
+
+	t.Run("should return error if a cluster has the name of the default cluster", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		_, err := NewMultiCluster(context.Background(), "us-east", mock.NewMockClient(ctrl), map[string]kubernetes.Client{"us-east": mock.NewMockClient(ctrl)})
+
+		assert.EqualError(t, err, `cluster "us-east" is already the name of the default cluster`)
+	})
+}
+
+func TestManager_DefaultNamespace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock.NewMockClient(ctrl)
+	mgr := New(context.Background(), client)
+	client.EXPECT().Namespace().Return("production").Times(2)
+	watcher, _ := safeWatcher(ctrl)
+	client.EXPECT().WatchEndpoints("production", "nginx").Return(watcher, nil)
+
+	_, err := mgr.Endpoints("", "nginx")
+	assert.Equal(t, ErrDataNotReady, err)
+	_, err = mgr.Endpoints("production", "nginx")
+	assert.Equal(t, ErrDataNotReady, err)
+	_, err = mgr.Endpoints("", "nginx")
+	assert.Equal(t, ErrDataNotReady, err)
+
+	assert.Equal(t, []string{"endpoints/production/nginx"}, mgr.PendingKeys())
+}
+
 func safeWatcher(ctrl *gomock.Controller) (watch.Interface, chan watch.Event) {
 	mockWatch := mock.NewMockInterface(ctrl)
 	dummyChannel := make(chan watch.Event, 1)
 	mockWatch.EXPECT().ResultChan().Return(dummyChannel).AnyTimes()
+	mockWatch.EXPECT().Stop().AnyTimes()
 	return mockWatch, dummyChannel
 }
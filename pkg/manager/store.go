@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"sort"
 	"sync"
 )
 
@@ -41,3 +42,25 @@ func (s *Store) Set(key string, value interface{}) {
 	s.data[key] = value
 	s.mutex.Unlock()
 }
+
+// Delete the given key from the store.
+// The operation is thread safe.
+func (s *Store) Delete(key string) {
+	s.mutex.Lock()
+	delete(s.data, key)
+	s.mutex.Unlock()
+}
+
+// Keys returns all keys present in the store in sorted order.
+// The operation is thread safe.
+func (s *Store) Keys() []string {
+	s.mutex.Lock()
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	s.mutex.Unlock()
+
+	sort.Strings(keys)
+	return keys
+}
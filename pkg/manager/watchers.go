@@ -16,3 +16,11 @@ func (w *watchers) exists(key string) bool {
 func (w *watchers) add(key string) {
 	w.data.Set(key, struct{}{})
 }
+
+func (w *watchers) remove(key string) {
+	w.data.Delete(key)
+}
+
+func (w *watchers) keys() []string {
+	return w.data.Keys()
+}